@@ -80,6 +80,18 @@ func New(path string) (PathSegments, bool) {
 	return ps, valid
 }
 
+// Copy returns a copy of the path segments which does not share memory with ps.
+func Copy(ps PathSegments) PathSegments {
+	if ps == nil {
+		return nil
+	}
+	cp := make(pathSegments, 0, ps.Size())
+	for i := 0; i < ps.Size(); i++ {
+		cp = append(cp, ps.Get(i))
+	}
+	return &cp
+}
+
 func (r *pathSegments) Add(ps PathSegment) {
 	*r = append(*r, ps)
 }
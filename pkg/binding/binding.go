@@ -0,0 +1,140 @@
+package binding
+
+// This package decodes the data present in the request, like the JSON
+// request body, in struct instances.
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Content-Type MIME of the most common data formats.
+const (
+	MIMEJSON              = "application/json"
+	MIMEHTML              = "text/html"
+	MIMEXML               = "application/xml"
+	MIMEXML2              = "text/xml"
+	MIMEPlain             = "text/plain"
+	MIMEPOSTForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartPOSTForm = "multipart/form-data"
+	MIMEPROTOBUF          = "application/x-protobuf"
+	MIMEMSGPACK           = "application/x-msgpack"
+	MIMEMSGPACK2          = "application/msgpack"
+	MIMEYAML              = "application/x-yaml"
+	MIMEYAML2             = "application/yaml"
+	MIMETOML              = "application/toml"
+	MIMECBOR              = "application/cbor"
+)
+
+// Binding describes the interface which needs to be implemented for binding the
+// data present in the request such as JSON request body, query parameters or
+// the form POST.
+type Binding interface {
+	Name() string
+	Bind(*http.Request, any) error
+}
+
+// BindingBody adds BindBody method to Binding. BindBody is similar with Bind,
+// but it reads the body from supplied bytes instead of req.Body.
+type BindingBody interface {
+	Binding
+	BindBody([]byte, any) error
+}
+
+// BindingURI binds the path parameters of the request, which are
+// not part of the http.Request.
+type BindingURI interface {
+	Name() string
+	BindURI(map[string][]string, any) error
+}
+
+// These implement the Binding interface and can be used to bind the data
+// present in the request to struct instances.
+var (
+	JSON          = jsonBinding{}
+	XML           = xmlBinding{}
+	Form          = formBinding{}
+	Query         = queryBinding{}
+	FormPost      = formPostBinding{}
+	FormMultipart = formMultipartBinding{}
+	ProtoBuf      = protobufBinding{}
+	YAML          = yamlBinding{}
+	URI           = uriBinding{}
+	Header        = headerBinding{}
+	TOML          = tomlBinding{}
+)
+
+var (
+	// ErrMissingBody is returned when the request has no body to decode.
+	ErrMissingBody = errors.New("missing request body")
+	// ErrBodyTooLarge is returned when the request body exceeds the maximum size.
+	ErrBodyTooLarge = errors.New("request body too large")
+	// ErrUnsupportedMediaType is returned when no binding exists for the content type.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// Error is returned when binding the request fails.
+type Error struct {
+	// Binding is the name of the binding that failed.
+	Binding string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Binding == "" {
+		return "binding: " + e.Err.Error()
+	}
+	return "binding " + e.Binding + ": " + e.Err.Error()
+}
+
+// Unwrap returns the wrapped error, to allow interoperability with errors.Is(), errors.As() and errors.Unwrap()
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the http status code matching the failure.
+func (e *Error) StatusCode() int {
+	switch {
+	case errors.Is(e.Err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(e.Err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// newError wraps the error of a binding in an Error, translating the errors
+// of reading the body in the matching sentinel error.
+func newError(name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		err = ErrBodyTooLarge
+	case errors.Is(err, io.EOF):
+		err = ErrMissingBody
+	}
+	return &Error{Binding: name, Err: err}
+}
+
+// ContentType returns the media type of the Content-Type header
+// without its parameters.
+func ContentType(header string) string {
+	if i := strings.IndexByte(header, ';'); i >= 0 {
+		header = header[:i]
+	}
+	return strings.TrimSpace(header)
+}
+
+func body(req *http.Request) (io.Reader, error) {
+	if req == nil || req.Body == nil || req.Body == http.NoBody {
+		return nil, ErrMissingBody
+	}
+	return req.Body, nil
+}
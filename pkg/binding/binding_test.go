@@ -0,0 +1,132 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	testdata "github.com/gin-gonic/gin/testdata/protoexample"
+	"github.com/idproxy/httpserver/pkg/codec"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+)
+
+type fooStruct struct {
+	Foo string `json:"foo" xml:"foo" yaml:"foo" toml:"foo" msgpack:"foo"`
+}
+
+func requestWithBody(method, path, body string) *http.Request {
+	return httptest.NewRequest(method, path, strings.NewReader(body))
+}
+
+func TestBindingDefault(t *testing.T) {
+	assert.Equal(t, JSON, Default(http.MethodPost, MIMEJSON))
+	assert.Equal(t, XML, Default(http.MethodPost, MIMEXML))
+	assert.Equal(t, XML, Default(http.MethodPut, MIMEXML2))
+	assert.Equal(t, ProtoBuf, Default(http.MethodPost, MIMEPROTOBUF))
+	assert.Equal(t, YAML, Default(http.MethodPost, MIMEYAML))
+	assert.Equal(t, TOML, Default(http.MethodPatch, MIMETOML))
+	assert.Equal(t, FormMultipart, Default(http.MethodPost, MIMEMultipartPOSTForm))
+	assert.Nil(t, Default(http.MethodPost, "application/unknown"))
+}
+
+func TestBindingBody(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		binding BindingBody
+		body    string
+	}{
+		{"json", JSON, `{"foo": "bar"}`},
+		{"xml", XML, `<?xml version="1.0" encoding="UTF-8"?><root><foo>bar</foo></root>`},
+		{"yaml", YAML, `foo: bar`},
+		{"toml", TOML, `foo = "bar"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.name, tt.binding.Name())
+
+			obj := fooStruct{}
+			assert.NoError(t, tt.binding.Bind(requestWithBody(http.MethodPost, "/", tt.body), &obj))
+			assert.Equal(t, "bar", obj.Foo)
+
+			obj = fooStruct{}
+			assert.NoError(t, tt.binding.BindBody([]byte(tt.body), &obj))
+			assert.Equal(t, "bar", obj.Foo)
+		})
+	}
+}
+
+func TestBindingProtoBuf(t *testing.T) {
+	test := &testdata.Test{
+		Label: proto.String("yes"),
+	}
+	data, _ := proto.Marshal(test)
+
+	obj := testdata.Test{}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	assert.NoError(t, ProtoBuf.Bind(req, &obj))
+	assert.Equal(t, "yes", *obj.Label)
+
+	err := ProtoBuf.BindBody(data, &fooStruct{})
+	assert.Error(t, err)
+}
+
+func TestBindingErrors(t *testing.T) {
+	var bindErr *Error
+
+	err := JSON.Bind(httptest.NewRequest(http.MethodPost, "/", nil), &fooStruct{})
+	assert.ErrorIs(t, err, ErrMissingBody)
+	assert.True(t, errors.As(err, &bindErr))
+	assert.Equal(t, "json", bindErr.Binding)
+	assert.Equal(t, http.StatusBadRequest, bindErr.StatusCode())
+
+	err = JSON.Bind(requestWithBody(http.MethodPost, "/", `{"foo":`), &fooStruct{})
+	assert.True(t, errors.As(err, &bindErr))
+	assert.NotErrorIs(t, err, ErrMissingBody)
+
+	req := requestWithBody(http.MethodPost, "/", `{"foo": "a long value"}`)
+	req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 4)
+	err = JSON.Bind(req, &fooStruct{})
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+	assert.True(t, errors.As(err, &bindErr))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, bindErr.StatusCode())
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, (&Error{Err: ErrUnsupportedMediaType}).StatusCode())
+}
+
+func TestBindingJSONDisallowUnknownFields(t *testing.T) {
+	EnableDecoderDisallowUnknownFields = true
+	defer func() {
+		EnableDecoderDisallowUnknownFields = false
+	}()
+
+	err := JSON.BindBody([]byte(`{"foo": "bar", "what": "this"}`), &fooStruct{})
+	assert.Error(t, err)
+}
+
+func TestBindingProtoJSONUnknownFields(t *testing.T) {
+	codec.SetJSON(codec.ProtoJSON{})
+	defer codec.SetJSON(nil)
+	body := []byte(`{"fileName": "a.proto", "unknown": 1}`)
+	assert.Error(t, JSON.BindBody(body, &sourcecontextpb.SourceContext{}))
+
+	codec.SetJSON(codec.NewProtoJSON())
+	m := &sourcecontextpb.SourceContext{}
+	assert.NoError(t, JSON.BindBody(body, m))
+	assert.Equal(t, "a.proto", m.FileName)
+
+	EnableDecoderDisallowUnknownFields = true
+	defer func() {
+		EnableDecoderDisallowUnknownFields = false
+	}()
+	assert.Error(t, JSON.BindBody(body, &sourcecontextpb.SourceContext{}))
+}
+
+func TestContentType(t *testing.T) {
+	assert.Equal(t, MIMEJSON, ContentType("application/json; charset=utf-8"))
+	assert.Equal(t, MIMEXML, ContentType(" application/xml "))
+	assert.Equal(t, "", ContentType(""))
+}
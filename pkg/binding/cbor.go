@@ -0,0 +1,45 @@
+//go:build !nocbor
+
+package binding
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/ugorji/go/codec"
+)
+
+// CBOR implements the Binding interface for CBOR request bodies,
+// it is defined here to support go build tag nocbor.
+var CBOR = cborBinding{}
+
+type cborBinding struct{}
+
+func (cborBinding) Name() string {
+	return "cbor"
+}
+
+func (b cborBinding) Bind(req *http.Request, obj any) error {
+	r, err := body(req)
+	if err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), decodeCBOR(r, obj))
+}
+
+func (b cborBinding) BindBody(body []byte, obj any) error {
+	return newError(b.Name(), decodeCBOR(bytes.NewReader(body), obj))
+}
+
+func decodeCBOR(r io.Reader, obj any) error {
+	return codec.NewDecoder(r, new(codec.CborHandle)).Decode(&obj)
+}
+
+// cborDefault returns the CBOR binding for the CBOR content type
+func cborDefault(contentType string) Binding {
+	if contentType == MIMECBOR {
+		return CBOR
+	}
+	return nil
+}
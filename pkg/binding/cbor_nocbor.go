@@ -0,0 +1,7 @@
+//go:build nocbor
+
+package binding
+
+func cborDefault(contentType string) Binding {
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build !nocbor
+
+package binding
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestBindingCBOR(t *testing.T) {
+	test := fooStruct{
+		Foo: "bar",
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	assert.NoError(t, codec.NewEncoder(buf, new(codec.CborHandle)).Encode(test))
+	data := buf.Bytes()
+
+	assert.Equal(t, CBOR, Default(http.MethodPost, MIMECBOR))
+
+	obj := fooStruct{}
+	assert.NoError(t, CBOR.Bind(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)), &obj))
+	assert.Equal(t, "bar", obj.Foo)
+
+	obj = fooStruct{}
+	assert.NoError(t, CBOR.BindBody(data, &obj))
+	assert.Equal(t, "bar", obj.Foo)
+
+	assert.Error(t, CBOR.BindBody([]byte{0xff}, &obj))
+}
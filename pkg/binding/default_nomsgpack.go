@@ -0,0 +1,32 @@
+//go:build nomsgpack
+
+package binding
+
+import "net/http"
+
+// Default returns the appropriate Binding instance based on the HTTP method
+// and the content type. It returns nil when no binding supports the content type.
+func Default(method, contentType string) Binding {
+	if method == http.MethodGet {
+		return Form
+	}
+
+	switch contentType {
+	case MIMEJSON:
+		return JSON
+	case MIMEXML, MIMEXML2:
+		return XML
+	case MIMEPROTOBUF:
+		return ProtoBuf
+	case MIMEYAML, MIMEYAML2:
+		return YAML
+	case MIMETOML:
+		return TOML
+	case MIMEMultipartPOSTForm:
+		return FormMultipart
+	case MIMEPOSTForm, "":
+		return Form
+	default:
+		return cborDefault(contentType)
+	}
+}
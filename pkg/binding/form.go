@@ -0,0 +1,50 @@
+package binding
+
+import "net/http"
+
+// DefaultMemory is the part of a multipart body kept in memory when parsing
+// the body, the remainder of the files is stored in temporary files.
+const DefaultMemory = 32 << 20
+
+type formBinding struct{}
+type formPostBinding struct{}
+type formMultipartBinding struct{}
+
+func (formBinding) Name() string {
+	return "form"
+}
+
+// Bind maps the query and the url encoded form body on the fields
+// tagged with `form`.
+func (b formBinding) Bind(req *http.Request, obj any) error {
+	if err := req.ParseForm(); err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), mapForm(obj, req.Form))
+}
+
+func (formPostBinding) Name() string {
+	return "form-urlencoded"
+}
+
+// Bind maps the url encoded form body on the fields tagged with `form`.
+func (b formPostBinding) Bind(req *http.Request, obj any) error {
+	if err := req.ParseForm(); err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), mapForm(obj, req.PostForm))
+}
+
+func (formMultipartBinding) Name() string {
+	return "multipart/form-data"
+}
+
+// Bind maps the values and files of the multipart form body on the fields
+// tagged with `form`. Files are bound to *multipart.FileHeader fields.
+// A body already parsed with http.Request.ParseMultipartForm is reused.
+func (b formMultipartBinding) Bind(req *http.Request, obj any) error {
+	if err := req.ParseMultipartForm(DefaultMemory); err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), mappingByPtr(obj, (*multipartRequest)(req), "form"))
+}
@@ -0,0 +1,181 @@
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappingBaseTypes(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	for _, tt := range []struct {
+		name   string
+		value  any
+		form   string
+		expect any
+	}{
+		{"int", int(0), "-9", int(-9)},
+		{"int8", int8(0), "-9", int8(-9)},
+		{"int64", int64(0), "9", int64(9)},
+		{"uint", uint(0), "9", uint(9)},
+		{"uint16", uint16(0), "9", uint16(9)},
+		{"bool", false, "true", true},
+		{"float32", float32(0), "9.1", float32(9.1)},
+		{"float64", float64(0), "9.1", float64(9.1)},
+		{"string", "", "test", "test"},
+		{"duration", time.Duration(0), "5s", 5 * time.Second},
+		{"ptr", (*int)(nil), "9", intPtr(9)},
+		{"slice", []int(nil), "9", []int{9}},
+		{"ptr slice", []*int(nil), "9", []*int{intPtr(9)}},
+		{"zero int", int(1), "", int(0)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// a struct with a single field of the type of value
+			typ := reflect.StructOf([]reflect.StructField{{
+				Name: "F",
+				Type: reflect.TypeOf(tt.value),
+				Tag:  `query:"field"`,
+			}})
+			s := reflect.New(typ)
+			s.Elem().Field(0).Set(reflect.ValueOf(tt.value))
+
+			err := mapQuery(s.Interface(), map[string][]string{"field": {tt.form}})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, s.Elem().Field(0).Interface())
+		})
+	}
+}
+
+func TestMappingDefault(t *testing.T) {
+	var s struct {
+		Page  int      `query:"page,default=1"`
+		Size  int      `query:"size,default=10"`
+		Tags  []string `query:"tags,default=a;b"`
+		Order string   `query:"order,default=asc"`
+	}
+	err := mapQuery(&s, map[string][]string{"size": {"20"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, s.Page)
+	assert.Equal(t, 20, s.Size)
+	assert.Equal(t, []string{"a", "b"}, s.Tags)
+	assert.Equal(t, "asc", s.Order)
+}
+
+func TestMappingTime(t *testing.T) {
+	var s struct {
+		Time      time.Time `query:"time"`
+		Date      time.Time `query:"date" time_format:"2006-01-02" time_utc:"1"`
+		Unix      time.Time `query:"unix" time_format:"unix"`
+		Undefined time.Time `query:"undefined"`
+	}
+	err := mapQuery(&s, map[string][]string{
+		"time": {"2019-01-20T16:02:58Z"},
+		"date": {"2019-01-20"},
+		"unix": {"1548000000"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "2019-01-20 16:02:58 +0000 UTC", s.Time.UTC().String())
+	assert.Equal(t, "2019-01-20 00:00:00 +0000 UTC", s.Date.String())
+	assert.Equal(t, int64(1548000000), s.Unix.Unix())
+	assert.True(t, s.Undefined.IsZero())
+
+	err = mapQuery(&s, map[string][]string{"date": {"20-01-2019"}})
+	assert.Error(t, err)
+}
+
+func TestMappingOnlyTaggedFields(t *testing.T) {
+	type nested struct {
+		Tenant string `header:"X-Tenant"`
+	}
+	var s struct {
+		ID     int    `uri:"id"`
+		Name   string `json:"name"`
+		Ignore string `query:"-"`
+		Nested nested
+	}
+	err := mapQuery(&s, map[string][]string{"Name": {"alice"}, "id": {"1"}, "Ignore": {"x"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "", s.Name)
+	assert.Equal(t, 0, s.ID)
+	assert.Equal(t, "", s.Ignore)
+
+	assert.NoError(t, mapHeader(&s, map[string][]string{"X-Tenant": {"acme"}}))
+	assert.Equal(t, "acme", s.Nested.Tenant)
+}
+
+func TestMappingErrors(t *testing.T) {
+	var s struct {
+		Page int `query:"page"`
+	}
+	err := mapQuery(&s, map[string][]string{"page": {"one"}})
+	assert.ErrorContains(t, err, `invalid query "page"`)
+
+	var u struct {
+		Ch chan int `query:"ch"`
+	}
+	err = mapQuery(&u, map[string][]string{"ch": {"1"}})
+	assert.ErrorIs(t, err, errUnknownType)
+}
+
+func TestBindingQueryHeaderURI(t *testing.T) {
+	var s struct {
+		ID     int      `uri:"id"`
+		Page   int      `query:"page"`
+		IDs    []int    `query:"ids"`
+		Tenant string   `header:"x-tenant"`
+		Accept []string `header:"Accept"`
+	}
+	req := httptest.NewRequest(http.MethodGet, "/users/1?page=2&ids=1&ids=2", nil)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Add("Accept", "text/plain")
+	req.Header.Add("Accept", "application/json")
+
+	assert.NoError(t, URI.BindURI(map[string][]string{"id": {"1"}}, &s))
+	assert.NoError(t, Query.Bind(req, &s))
+	assert.NoError(t, Header.Bind(req, &s))
+
+	assert.Equal(t, 1, s.ID)
+	assert.Equal(t, 2, s.Page)
+	assert.Equal(t, []int{1, 2}, s.IDs)
+	assert.Equal(t, "acme", s.Tenant)
+	assert.Equal(t, []string{"text/plain", "application/json"}, s.Accept)
+}
+
+func TestBindingForm(t *testing.T) {
+	var s struct {
+		Email string `form:"email"`
+		Page  int    `form:"page"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/?page=3", strings.NewReader("email=alice%40example.com"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+
+	assert.Equal(t, Form, Default(http.MethodPost, MIMEPOSTForm))
+	assert.Equal(t, Form, Default(http.MethodGet, ""))
+	assert.NoError(t, Form.Bind(req, &s))
+	assert.Equal(t, "alice@example.com", s.Email)
+	assert.Equal(t, 3, s.Page)
+
+	s.Page = 0
+	req = httptest.NewRequest(http.MethodPost, "/?page=3", strings.NewReader("email=bob%40example.com"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	assert.NoError(t, FormPost.Bind(req, &s))
+	assert.Equal(t, "bob@example.com", s.Email)
+	assert.Equal(t, 0, s.Page)
+}
+
+func TestMappingMap(t *testing.T) {
+	m := map[string]string{}
+	assert.NoError(t, mapForm(&m, map[string][]string{"a": {"1", "2"}}))
+	assert.Equal(t, map[string]string{"a": "2"}, m)
+
+	ms := map[string][]string{}
+	assert.NoError(t, mapForm(ms, map[string][]string{"a": {"1", "2"}}))
+	assert.Equal(t, map[string][]string{"a": {"1", "2"}}, ms)
+}
@@ -0,0 +1,49 @@
+package binding
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/idproxy/httpserver/pkg/codec"
+)
+
+// EnableDecoderUseNumber is used to call the UseNumber method on the JSON
+// Decoder instance. UseNumber causes the Decoder to unmarshal a number into an
+// any as a Number instead of as a float64.
+var EnableDecoderUseNumber = false
+
+// EnableDecoderDisallowUnknownFields is used to call the DisallowUnknownFields method
+// on the JSON Decoder instance. DisallowUnknownFields causes the Decoder to
+// return an error when the destination is a struct and the input contains object
+// keys which do not match any non-ignored, exported fields in the destination.
+var EnableDecoderDisallowUnknownFields = false
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string {
+	return "json"
+}
+
+func (b jsonBinding) Bind(req *http.Request, obj any) error {
+	r, err := body(req)
+	if err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), decodeJSON(r, obj))
+}
+
+func (b jsonBinding) BindBody(body []byte, obj any) error {
+	return newError(b.Name(), decodeJSON(bytes.NewReader(body), obj))
+}
+
+func decodeJSON(r io.Reader, obj any) error {
+	decoder := codec.GetJSON().NewDecoder(r)
+	if EnableDecoderUseNumber {
+		decoder.UseNumber()
+	}
+	if EnableDecoderDisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(obj)
+}
@@ -0,0 +1,38 @@
+//go:build !nomsgpack
+
+package binding
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/ugorji/go/codec"
+)
+
+// MsgPack implements the Binding interface for msgpack request bodies,
+// it is defined here to support go build tag nomsgpack.
+var MsgPack = msgpackBinding{}
+
+type msgpackBinding struct{}
+
+func (msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+func (b msgpackBinding) Bind(req *http.Request, obj any) error {
+	r, err := body(req)
+	if err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), decodeMsgPack(r, obj))
+}
+
+func (b msgpackBinding) BindBody(body []byte, obj any) error {
+	return newError(b.Name(), decodeMsgPack(bytes.NewReader(body), obj))
+}
+
+func decodeMsgPack(r io.Reader, obj any) error {
+	cdc := new(codec.MsgpackHandle)
+	return codec.NewDecoder(r, cdc).Decode(&obj)
+}
@@ -0,0 +1,35 @@
+//go:build !nomsgpack
+
+package binding
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestBindingMsgPack(t *testing.T) {
+	test := fooStruct{
+		Foo: "bar",
+	}
+
+	h := new(codec.MsgpackHandle)
+	buf := bytes.NewBuffer([]byte{})
+	assert.NoError(t, codec.NewEncoder(buf, h).Encode(test))
+	data := buf.Bytes()
+
+	assert.Equal(t, MsgPack, Default(http.MethodPost, MIMEMSGPACK))
+	assert.Equal(t, MsgPack, Default(http.MethodPost, MIMEMSGPACK2))
+
+	obj := fooStruct{}
+	assert.NoError(t, MsgPack.Bind(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)), &obj))
+	assert.Equal(t, "bar", obj.Foo)
+
+	obj = fooStruct{}
+	assert.NoError(t, MsgPack.BindBody(data, &obj))
+	assert.Equal(t, "bar", obj.Foo)
+}
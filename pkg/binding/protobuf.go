@@ -0,0 +1,35 @@
+package binding
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type protobufBinding struct{}
+
+func (protobufBinding) Name() string {
+	return "protobuf"
+}
+
+func (b protobufBinding) Bind(req *http.Request, obj any) error {
+	r, err := body(req)
+	if err != nil {
+		return newError(b.Name(), err)
+	}
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return newError(b.Name(), err)
+	}
+	return b.BindBody(buf, obj)
+}
+
+func (b protobufBinding) BindBody(body []byte, obj any) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return newError(b.Name(), errors.New("obj is not ProtoMessage"))
+	}
+	return newError(b.Name(), proto.Unmarshal(body, msg))
+}
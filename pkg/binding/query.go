@@ -0,0 +1,15 @@
+package binding
+
+import "net/http"
+
+type queryBinding struct{}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+// Bind maps the query string on the fields tagged with `query`.
+func (b queryBinding) Bind(req *http.Request, obj any) error {
+	values := req.URL.Query()
+	return newError(b.Name(), mapQuery(obj, values))
+}
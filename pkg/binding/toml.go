@@ -0,0 +1,31 @@
+package binding
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+type tomlBinding struct{}
+
+func (tomlBinding) Name() string {
+	return "toml"
+}
+
+func (b tomlBinding) Bind(req *http.Request, obj any) error {
+	r, err := body(req)
+	if err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), decodeTOML(r, obj))
+}
+
+func (b tomlBinding) BindBody(body []byte, obj any) error {
+	return newError(b.Name(), decodeTOML(bytes.NewReader(body), obj))
+}
+
+func decodeTOML(r io.Reader, obj any) error {
+	return toml.NewDecoder(r).Decode(obj)
+}
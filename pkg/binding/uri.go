@@ -0,0 +1,12 @@
+package binding
+
+type uriBinding struct{}
+
+func (uriBinding) Name() string {
+	return "uri"
+}
+
+// BindURI maps the path parameters on the fields tagged with `uri`.
+func (b uriBinding) BindURI(m map[string][]string, obj any) error {
+	return newError(b.Name(), mapURI(obj, m))
+}
@@ -0,0 +1,40 @@
+package binding
+
+import (
+	"reflect"
+
+	"github.com/idproxy/httpserver/pkg/validate"
+)
+
+// StructValidator is the minimal interface which needs to be implemented in
+// order for it to be used as the validator engine for ensuring the correctness
+// of the request.
+type StructValidator interface {
+	// ValidateStruct can receive any kind of type and it should never panic, even if the configuration is not right.
+	// If the received type is a slice|array, the validation should be performed travel on every element.
+	// If the received type is not a struct or slice|array, any validation should be skipped and nil must be returned.
+	// If the received type is a struct or pointer to a struct, the validation should be performed.
+	// If the struct is not valid or the validation itself fails, a descriptive error should be returned.
+	// Otherwise nil must be returned.
+	ValidateStruct(any) error
+
+	// Engine returns the underlying validator engine which powers the
+	// StructValidator implementation.
+	Engine() any
+}
+
+// Validator is the default validator which implements the StructValidator
+// interface, it evaluates the rules of the `validate` struct tags.
+// Custom rules are registered through validate.Validator.RegisterRule.
+var Validator StructValidator = validate.New()
+
+// Validate validates the bound obj with the Validator.
+func Validate(obj any) error {
+	if Validator == nil || obj == nil {
+		return nil
+	}
+	if v := reflect.ValueOf(obj); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+	return Validator.ValidateStruct(obj)
+}
@@ -0,0 +1,30 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+)
+
+type xmlBinding struct{}
+
+func (xmlBinding) Name() string {
+	return "xml"
+}
+
+func (b xmlBinding) Bind(req *http.Request, obj any) error {
+	r, err := body(req)
+	if err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), decodeXML(r, obj))
+}
+
+func (b xmlBinding) BindBody(body []byte, obj any) error {
+	return newError(b.Name(), decodeXML(bytes.NewReader(body), obj))
+}
+
+func decodeXML(r io.Reader, obj any) error {
+	return xml.NewDecoder(r).Decode(obj)
+}
@@ -0,0 +1,31 @@
+package binding
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlBinding struct{}
+
+func (yamlBinding) Name() string {
+	return "yaml"
+}
+
+func (b yamlBinding) Bind(req *http.Request, obj any) error {
+	r, err := body(req)
+	if err != nil {
+		return newError(b.Name(), err)
+	}
+	return newError(b.Name(), decodeYAML(r, obj))
+}
+
+func (b yamlBinding) BindBody(body []byte, obj any) error {
+	return newError(b.Name(), decodeYAML(bytes.NewReader(body), obj))
+}
+
+func decodeYAML(r io.Reader, obj any) error {
+	return yaml.NewDecoder(r).Decode(obj)
+}
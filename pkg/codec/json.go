@@ -0,0 +1,82 @@
+// Package codec contains the JSON codec used to render and bind JSON.
+// The default codec is selected at build time through the build tags of
+// internal/json (jsoniter, go_json or sonic, encoding/json otherwise) and can
+// be replaced at runtime with SetJSON.
+package codec
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/idproxy/httpserver/internal/json"
+)
+
+// JSON encodes and decodes JSON.
+type JSON interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	MarshalIndent(v any, prefix, indent string) ([]byte, error)
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes JSON values to an output stream.
+type Encoder interface {
+	Encode(v any) error
+	SetEscapeHTML(on bool)
+	SetIndent(prefix, indent string)
+}
+
+// Decoder reads JSON values from an input stream.
+type Decoder interface {
+	Decode(v any) error
+	UseNumber()
+	DisallowUnknownFields()
+}
+
+// DefaultJSON is the JSON codec selected by the build tags.
+var DefaultJSON JSON = defaultJSON{}
+
+// jsonCodec holds the process-wide JSON codec
+var jsonCodec atomic.Pointer[JSON]
+
+// SetJSON replaces the process-wide JSON codec, nil restores DefaultJSON.
+// It is the only way to replace the codec, which is shared by all the servers
+// of the process, and is meant to be called before serving requests.
+func SetJSON(c JSON) {
+	if c == nil {
+		c = DefaultJSON
+	}
+	jsonCodec.Store(&c)
+}
+
+// GetJSON returns the process-wide JSON codec.
+func GetJSON() JSON {
+	if c := jsonCodec.Load(); c != nil {
+		return *c
+	}
+	return DefaultJSON
+}
+
+// defaultJSON is the JSON codec of internal/json
+type defaultJSON struct{}
+
+func (defaultJSON) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (defaultJSON) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (defaultJSON) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	return json.MarshalIndent(v, prefix, indent)
+}
+
+func (defaultJSON) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (defaultJSON) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
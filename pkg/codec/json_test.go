@@ -0,0 +1,100 @@
+package codec
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+)
+
+type upperJSON struct {
+	JSON
+}
+
+func (c upperJSON) Marshal(v any) ([]byte, error) {
+	b, err := c.JSON.Marshal(v)
+	return bytes.ToUpper(b), err
+}
+
+func TestSetJSON(t *testing.T) {
+	assert.Equal(t, DefaultJSON, GetJSON())
+
+	SetJSON(upperJSON{DefaultJSON})
+	b, err := GetJSON().Marshal(map[string]string{"a": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"A":"B"}`, string(b))
+
+	SetJSON(nil)
+	assert.Equal(t, DefaultJSON, GetJSON())
+}
+
+func TestDefaultJSONDecoder(t *testing.T) {
+	var v struct {
+		A int `json:"a"`
+	}
+	dec := DefaultJSON.NewDecoder(strings.NewReader(`{"a": 1, "b": 2}`))
+	dec.DisallowUnknownFields()
+	assert.Error(t, dec.Decode(&v))
+
+	var n any
+	dec = DefaultJSON.NewDecoder(strings.NewReader(`1.0`))
+	dec.UseNumber()
+	assert.NoError(t, dec.Decode(&n))
+	assert.Equal(t, "1.0", n.(interface{ String() string }).String())
+}
+
+func TestProtoJSON(t *testing.T) {
+	c := ProtoJSON{}
+
+	b, err := c.Marshal(durationpb.New(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, `"1s"`, string(b))
+
+	b, err = c.Marshal(map[string]int{"a": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(b))
+
+	d := &durationpb.Duration{}
+	assert.NoError(t, c.Unmarshal([]byte(`"2s"`), d))
+	assert.Equal(t, 2*time.Second, d.AsDuration())
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.NewEncoder(&buf).Encode(durationpb.New(time.Minute)))
+	assert.NoError(t, c.NewEncoder(&buf).Encode([]int{1}))
+	assert.Equal(t, "\"60s\"\n[1]\n", buf.String())
+
+	d = &durationpb.Duration{}
+	assert.NoError(t, c.NewDecoder(strings.NewReader(`"3s"`)).Decode(d))
+	assert.Equal(t, 3*time.Second, d.AsDuration())
+	assert.ErrorIs(t, c.NewDecoder(strings.NewReader("")).Decode(d), io.EOF)
+}
+
+func TestProtoJSONUnknownFields(t *testing.T) {
+	body := `{"fileName": "a.proto", "unknown": 1}`
+
+	// Unmarshal and the decoder apply the same options
+	c := ProtoJSON{}
+	assert.Error(t, c.Unmarshal([]byte(body), &sourcecontextpb.SourceContext{}))
+	assert.Error(t, c.NewDecoder(strings.NewReader(body)).Decode(&sourcecontextpb.SourceContext{}))
+
+	c = NewProtoJSON()
+	m := &sourcecontextpb.SourceContext{}
+	assert.NoError(t, c.Unmarshal([]byte(body), m))
+	assert.Equal(t, "a.proto", m.FileName)
+	m = &sourcecontextpb.SourceContext{}
+	assert.NoError(t, c.NewDecoder(strings.NewReader(body)).Decode(m))
+	assert.Equal(t, "a.proto", m.FileName)
+
+	// the decoder can only be stricter than the options
+	dec := c.NewDecoder(strings.NewReader(body))
+	dec.DisallowUnknownFields()
+	assert.Error(t, dec.Decode(&sourcecontextpb.SourceContext{}))
+	dec = ProtoJSON{}.NewDecoder(strings.NewReader(body))
+	dec.DisallowUnknownFields()
+	assert.Error(t, dec.Decode(&sourcecontextpb.SourceContext{}))
+}
@@ -0,0 +1,139 @@
+package codec
+
+import (
+	"bytes"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoJSON is a JSON codec encoding and decoding protobuf messages with
+// protojson, following the canonical JSON mapping of protobuf, and all other
+// values with Fallback, DefaultJSON when nil.
+//
+// Unknown fields of protobuf messages are rejected unless
+// UnmarshalOptions.DiscardUnknown is set, by Unmarshal and by the decoders
+// alike. Decoder.DisallowUnknownFields rejects them in any case.
+type ProtoJSON struct {
+	MarshalOptions   protojson.MarshalOptions
+	UnmarshalOptions protojson.UnmarshalOptions
+	Fallback         JSON
+}
+
+var _ JSON = ProtoJSON{}
+
+// NewProtoJSON returns a ProtoJSON codec which, like encoding/json, ignores
+// the unknown fields of protobuf messages.
+func NewProtoJSON() ProtoJSON {
+	return ProtoJSON{UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true}}
+}
+
+func (c ProtoJSON) fallback() JSON {
+	if c.Fallback == nil {
+		return DefaultJSON
+	}
+	return c.Fallback
+}
+
+func (c ProtoJSON) Marshal(v any) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return c.MarshalOptions.Marshal(m)
+	}
+	return c.fallback().Marshal(v)
+}
+
+func (c ProtoJSON) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return c.UnmarshalOptions.Unmarshal(data, m)
+	}
+	return c.fallback().Unmarshal(data, v)
+}
+
+func (c ProtoJSON) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		opts := c.MarshalOptions
+		opts.Multiline = true
+		opts.Indent = indent
+		return opts.Marshal(m)
+	}
+	return c.fallback().MarshalIndent(v, prefix, indent)
+}
+
+func (c ProtoJSON) NewEncoder(w io.Writer) Encoder {
+	return &protoEncoder{c: c, w: w, enc: c.fallback().NewEncoder(w)}
+}
+
+func (c ProtoJSON) NewDecoder(r io.Reader) Decoder {
+	return &protoDecoder{c: c, r: r, dec: c.fallback().NewDecoder(r)}
+}
+
+// protoEncoder encodes protobuf messages with protojson and all other values
+// with the encoder of the fallback codec.
+type protoEncoder struct {
+	c      ProtoJSON
+	w      io.Writer
+	enc    Encoder
+	indent string
+}
+
+func (e *protoEncoder) Encode(v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return e.enc.Encode(v)
+	}
+	opts := e.c.MarshalOptions
+	if e.indent != "" {
+		opts.Multiline = true
+		opts.Indent = e.indent
+	}
+	b, err := opts.Marshal(m)
+	if err != nil {
+		return err
+	}
+	// like json.Encoder every value is followed by a newline
+	_, err = e.w.Write(append(b, '\n'))
+	return err
+}
+
+func (e *protoEncoder) SetEscapeHTML(on bool) {
+	e.enc.SetEscapeHTML(on)
+}
+
+func (e *protoEncoder) SetIndent(prefix, indent string) {
+	e.indent = indent
+	e.enc.SetIndent(prefix, indent)
+}
+
+// protoDecoder decodes protobuf messages with protojson and all other values
+// with the decoder of the fallback codec. A protobuf message consumes the
+// remainder of the stream.
+type protoDecoder struct {
+	c   ProtoJSON
+	r   io.Reader
+	dec Decoder
+}
+
+func (d *protoDecoder) Decode(v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return d.dec.Decode(v)
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(d.r); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		return io.EOF
+	}
+	return d.c.UnmarshalOptions.Unmarshal(buf.Bytes(), m)
+}
+
+func (d *protoDecoder) UseNumber() {
+	d.dec.UseNumber()
+}
+
+func (d *protoDecoder) DisallowUnknownFields() {
+	d.c.UnmarshalOptions.DiscardUnknown = false
+	d.dec.DisallowUnknownFields()
+}
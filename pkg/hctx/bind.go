@@ -0,0 +1,132 @@
+package hctx
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/idproxy/httpserver/pkg/binding"
+	"github.com/idproxy/httpserver/pkg/render"
+	"github.com/idproxy/httpserver/pkg/validate"
+)
+
+// ContentType returns the media type of the Content-Type header of the request.
+func (c *context) ContentType() string {
+	return binding.ContentType(c.r.Header.Get("Content-Type"))
+}
+
+// Bind checks the Method and Content-Type to select a binding engine automatically,
+// decodes the request into obj and validates it.
+// When binding fails the request is aborted with the status matching the error
+// (400, 413 or 415) and the error is attached with ErrorTypeBind.
+// When validation fails a 400 problem response listing the invalid fields is written.
+// Use ShouldBind to handle the error in the handler.
+func (c *context) Bind(obj any) error {
+	return c.MustBindWith(obj, binding.Default(c.GetMethod(), c.ContentType()))
+}
+
+// MustBindWith binds the passed struct pointer using the specified binding engine.
+// When binding fails the request is aborted with the status matching the error
+// and the error is attached with ErrorTypeBind.
+func (c *context) MustBindWith(obj any, b binding.Binding) error {
+	if err := c.ShouldBindWith(obj, b); err != nil {
+		abortWithBindError(c, err)
+		return err
+	}
+	return nil
+}
+
+// abortWithBindError aborts the request with the status matching the error.
+// Validation errors are rendered as a problem response listing the invalid fields.
+func abortWithBindError(c Context, err error) {
+	code := bindErrorStatus(err)
+	c.AbortWithError(code, err).SetType(ErrorTypeBind)
+
+	var verrs validate.Errors
+	if !errors.As(err, &verrs) {
+		return
+	}
+	problem := render.NewProblem(code, "the request failed validation")
+	problem.Extensions = map[string]any{"errors": verrs}
+	c.Render(code, problem)
+}
+
+// ShouldBind checks the Method and Content-Type to select a binding engine automatically,
+// decodes the request into obj and validates it.
+// Like Bind, but the response is not touched when binding fails.
+func (c *context) ShouldBind(obj any) error {
+	return c.ShouldBindWith(obj, binding.Default(c.GetMethod(), c.ContentType()))
+}
+
+// ShouldBindWith binds the passed struct pointer using the specified binding engine
+// and validates it with binding.Validator.
+// The request body is limited to the maximum body size of the server.
+func (c *context) ShouldBindWith(obj any, b binding.Binding) error {
+	if err := c.decodeWith(obj, b); err != nil {
+		return err
+	}
+	return binding.Validate(obj)
+}
+
+// ShouldBindURI binds the path parameters into obj, using the `uri` struct tags,
+// and validates it.
+func (c *context) ShouldBindURI(obj any) error {
+	if err := c.decodeURI(obj); err != nil {
+		return err
+	}
+	return binding.Validate(obj)
+}
+
+// ShouldBindQuery binds the query string into obj, using the `query` struct tags,
+// and validates it.
+func (c *context) ShouldBindQuery(obj any) error {
+	return c.ShouldBindWith(obj, binding.Query)
+}
+
+// ShouldBindHeader binds the request headers into obj, using the `header` struct tags,
+// and validates it.
+func (c *context) ShouldBindHeader(obj any) error {
+	return c.ShouldBindWith(obj, binding.Header)
+}
+
+// decodeWith decodes the request into obj with the binding, without validation
+func (c *context) decodeWith(obj any, b binding.Binding) error {
+	if b == nil {
+		return &binding.Error{Err: binding.ErrUnsupportedMediaType}
+	}
+	if b == binding.FormMultipart {
+		// parse the body with the multipart limits of the server
+		if _, err := c.MultipartForm(); err != nil {
+			return err
+		}
+	}
+	c.limitBody(c.maxBodySize)
+	return b.Bind(c.r, obj)
+}
+
+// decodeURI decodes the path parameters into obj, without validation
+func (c *context) decodeURI(obj any) error {
+	m := make(map[string][]string, c.params.Size())
+	for k, v := range c.params.List() {
+		m[k] = []string{v}
+	}
+	return binding.URI.BindURI(m, obj)
+}
+
+// limitBody limits the size of the request body to max bytes,
+// the first limit applied to the body is kept.
+func (c *context) limitBody(max int64) {
+	if c.bodyLimited || max <= 0 || c.r.Body == nil {
+		return
+	}
+	c.r.Body = http.MaxBytesReader(c.w, c.r.Body, max)
+	c.bodyLimited = true
+}
+
+// bindErrorStatus returns the http status code matching the binding error
+func bindErrorStatus(err error) int {
+	var bindErr *binding.Error
+	if errors.As(err, &bindErr) {
+		return bindErr.StatusCode()
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,165 @@
+package hctx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/idproxy/httpserver/pkg/codec"
+)
+
+// ErrorType is an unsigned 64-bit error code as defined in the http server spec.
+type ErrorType uint64
+
+const (
+	// ErrorTypeBind is used when binding the request fails.
+	ErrorTypeBind ErrorType = 1 << 63
+	// ErrorTypeRender is used when rendering the response fails.
+	ErrorTypeRender ErrorType = 1 << 62
+	// ErrorTypePrivate indicates a private error, which is not exposed to the client.
+	ErrorTypePrivate ErrorType = 1 << 0
+	// ErrorTypePublic indicates a public error, which can be exposed to the client.
+	ErrorTypePublic ErrorType = 1 << 1
+	// ErrorTypeAny indicates any other error.
+	ErrorTypeAny ErrorType = 1<<64 - 1
+)
+
+// Error represents an error collected during the processing of a request.
+type Error struct {
+	Err  error
+	Type ErrorType
+	Meta any
+}
+
+// ErrorList is the list of errors collected during the processing of a request.
+type ErrorList []*Error
+
+var _ error = (*Error)(nil)
+
+// SetType sets the error's type.
+func (e *Error) SetType(flags ErrorType) *Error {
+	e.Type = flags
+	return e
+}
+
+// SetMeta sets the error's meta data.
+func (e *Error) SetMeta(data any) *Error {
+	e.Meta = data
+	return e
+}
+
+// JSON creates a properly formatted JSON representation of the error.
+func (e *Error) JSON() any {
+	jsonData := map[string]any{}
+	if e.Meta != nil {
+		value := reflect.ValueOf(e.Meta)
+		switch value.Kind() {
+		case reflect.Struct:
+			return e.Meta
+		case reflect.Map:
+			for _, key := range value.MapKeys() {
+				jsonData[key.String()] = value.MapIndex(key).Interface()
+			}
+		default:
+			jsonData["meta"] = e.Meta
+		}
+	}
+	if _, ok := jsonData["error"]; !ok {
+		jsonData["error"] = e.Error()
+	}
+	return jsonData
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return codec.GetJSON().Marshal(e.JSON())
+}
+
+// Error implements the error interface.
+func (e Error) Error() string {
+	return e.Err.Error()
+}
+
+// IsType judges one error.
+func (e *Error) IsType(flags ErrorType) bool {
+	return (e.Type & flags) > 0
+}
+
+// Unwrap returns the wrapped error, to allow interoperability with errors.Is(), errors.As() and errors.Unwrap()
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ByType returns a readonly copy filtered the byte.
+// ie ByType(ErrorTypePublic) returns a slice of errors with type=ErrorTypePublic.
+func (l ErrorList) ByType(typ ErrorType) ErrorList {
+	if len(l) == 0 {
+		return nil
+	}
+	if typ == ErrorTypeAny {
+		return l
+	}
+	var result ErrorList
+	for _, msg := range l {
+		if msg.IsType(typ) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// Last returns the last error in the slice. It returns nil if the array is empty.
+func (l ErrorList) Last() *Error {
+	if length := len(l); length > 0 {
+		return l[length-1]
+	}
+	return nil
+}
+
+// Errors returns an array with all the error messages.
+func (l ErrorList) Errors() []string {
+	if len(l) == 0 {
+		return nil
+	}
+	errorStrings := make([]string, len(l))
+	for i, err := range l {
+		errorStrings[i] = err.Error()
+	}
+	return errorStrings
+}
+
+// JSON creates a properly formatted JSON representation of the error list.
+func (l ErrorList) JSON() any {
+	switch length := len(l); length {
+	case 0:
+		return nil
+	case 1:
+		return l.Last().JSON()
+	default:
+		jsonData := make([]any, length)
+		for i, err := range l {
+			jsonData[i] = err.JSON()
+		}
+		return jsonData
+	}
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (l ErrorList) MarshalJSON() ([]byte, error) {
+	return codec.GetJSON().Marshal(l.JSON())
+}
+
+// String returns the error messages, one per line.
+func (l ErrorList) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+	var buffer strings.Builder
+	for i, msg := range l {
+		fmt.Fprintf(&buffer, "Error #%02d: %s\n", i+1, msg.Err)
+		if msg.Meta != nil {
+			fmt.Fprintf(&buffer, "     Meta: %v\n", msg.Meta)
+		}
+	}
+	return buffer.String()
+}
@@ -0,0 +1,111 @@
+package hctx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/idproxy/httpserver/pkg/render"
+)
+
+// ErrIsDirectory is returned when a file is requested but the path is a directory.
+var ErrIsDirectory = errors.New("file is a directory")
+
+// File writes the specified file into the body stream in an efficient way.
+// It answers byte-range and conditional requests.
+func (c *context) File(filePath string) {
+	c.serveFile(nil, filePath, nil)
+}
+
+// FileFromFS writes the specified file from the file system into the body stream.
+// The name is cleaned, so it can not escape the file system.
+func (c *context) FileFromFS(name string, fsys fs.FS) {
+	c.serveFile(fsys, name, nil)
+}
+
+// FileAttachment writes the specified file into the body stream with a
+// Content-Disposition header, so the client downloads it with the given filename.
+func (c *context) FileAttachment(filePath, filename string) {
+	c.serveFile(nil, filePath, map[string]string{
+		"Content-Disposition": render.ContentDisposition("attachment", filename),
+	})
+}
+
+// serveFile serves the named file of the file system, or of the OS when fsys is nil
+func (c *context) serveFile(fsys fs.FS, name string, headers map[string]string) {
+	f, info, err := openFile(fsys, name)
+	if err != nil {
+		// the body of the error is written by the error handler of the server
+		c.AbortWithError(fileErrorStatus(err), err)
+		return
+	}
+	defer f.Close()
+
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	if etag := FileETag(info); etag != "" {
+		headers["ETag"] = etag
+	}
+	c.Render(http.StatusOK, render.Reader{
+		ContentType:   mime.TypeByExtension(path.Ext(info.Name())),
+		ContentLength: info.Size(),
+		Reader:        f,
+		Headers:       headers,
+		Request:       c.r,
+		ModTime:       info.ModTime(),
+	})
+}
+
+// openFile opens the named regular file of the file system, or of the OS when fsys is nil
+func openFile(fsys fs.FS, name string) (fs.File, fs.FileInfo, error) {
+	var f fs.File
+	var err error
+	if fsys == nil {
+		f, err = os.Open(name)
+	} else {
+		name = path.Clean("/" + name)[1:]
+		if name == "" {
+			name = "."
+		}
+		f, err = fsys.Open(name)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, fmt.Errorf("%s: %w", name, ErrIsDirectory)
+	}
+	return f, info, nil
+}
+
+// fileErrorStatus returns the status code of the error opening a file
+func fileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, ErrIsDirectory), errors.Is(err, fs.ErrInvalid):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// FileETag returns a strong entity tag of the file derived from its
+// modification time and size, or "" when the modification time is unknown.
+func FileETag(info fs.FileInfo) string {
+	if info.ModTime().IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
@@ -1,14 +1,21 @@
 package hctx
 
 import (
+	gocontext "context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"math"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/idproxy/httpserver/internal/pathsegment"
+	"github.com/idproxy/httpserver/pkg/binding"
 	"github.com/idproxy/httpserver/pkg/params"
 	"github.com/idproxy/httpserver/pkg/render"
 )
@@ -16,9 +23,50 @@ import (
 // used to abort the handler processing
 const abortIndex int8 = math.MaxInt8 >> 1
 
+// DefaultSecureJSONPrefix is the prefix of SecureJSON responses when none is configured
+const DefaultSecureJSONPrefix = "while(1);"
+
+// ErrNoHTMLRender is used when HTML is rendered without an HTMLRender configured.
+var ErrNoHTMLRender = errors.New("no HTML render configured")
+
+// ErrCopyWrite is returned when the response is written through a copy of the context.
+var ErrCopyWrite = errors.New("cannot write the response through a copy of the context")
+
+type contextKey struct{}
+
+// ContextKey is the key under which the Context can be found in the values
+// of the context.Context it implements, see FromContext.
+var ContextKey = contextKey{}
+
 type Context interface {
+	// Context delegates to the context of the http request, so downstream
+	// clients observe the cancellation when the client goes away.
+	gocontext.Context
+
 	/************ CONTEXT INIT **************/
 	Init(*Config)
+	/************ CONTEXT VALUES ************/
+	WithValue(key, val any)
+	WithCancel() gocontext.CancelFunc
+	WithTimeout(timeout time.Duration) gocontext.CancelFunc
+	Copy() Context
+	/************ CONTEXT KEYS **************/
+	Set(key string, value any)
+	Get(key string) (value any, exists bool)
+	MustGet(key string) any
+	Keys() map[string]any
+	GetString(key string) string
+	GetBool(key string) bool
+	GetInt(key string) int
+	GetInt64(key string) int64
+	GetUint(key string) uint
+	GetUint64(key string) uint64
+	GetFloat64(key string) float64
+	GetTime(key string) time.Time
+	GetDuration(key string) time.Duration
+	GetStringSlice(key string) []string
+	GetStringMap(key string) map[string]any
+	GetStringMapString(key string) map[string]string
 	/************ CONTEXT PROCESSING ********/
 	UseRawPath() bool
 	GetStatus() int
@@ -44,13 +92,59 @@ type Context interface {
 	Next()
 	Abort()
 	AbortWithStatus(code int)
+	AbortWithError(code int, err error) *Error
+	OnFinish(fn func())
+	Finish()
+	/************ ERRORS ********************/
+	Error(err error) *Error
+	Errors() ErrorList
+
+	/************ BINDING *****************/
+	ContentType() string
+	Bind(obj any) error
+	MustBindWith(obj any, b binding.Binding) error
+	ShouldBind(obj any) error
+	ShouldBindWith(obj any, b binding.Binding) error
+	ShouldBindURI(obj any) error
+	ShouldBindQuery(obj any) error
+	ShouldBindHeader(obj any) error
+	ShouldBindRequest(obj any) error
+	/************ MULTIPART ****************/
+	MultipartForm() (*multipart.Form, error)
+	FormFile(name string) (*multipart.FileHeader, error)
+	SaveUploadedFile(file *multipart.FileHeader, dst string) error
+	EachPart(fn func(p *Part) error) error
 
 	/************ RENDER RESPONSE ***********/
 	Status(code int)
 	String(code int, format string, values ...any)
 	JSON(code int, obj any)
+	IndentedJSON(code int, obj any)
+	SecureJSON(code int, obj any)
+	JSONP(code int, obj any)
+	AsciiJSON(code int, obj any)
+	PureJSON(code int, obj any)
+	XML(code int, obj any)
+	YAML(code int, obj any)
+	TOML(code int, obj any)
+	ProtoBuf(code int, obj any)
+	Data(code int, contentType string, data []byte)
+	DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string)
+	File(filePath string)
+	FileFromFS(name string, fsys fs.FS)
+	FileAttachment(filePath, filename string)
+	Redirect(code int, location string)
+	HTML(code int, name string, obj any)
+	StreamNDJSON(code int, src render.Source)
+	StreamJSONArray(code int, src render.Source)
+	Stream(step func(w io.Writer) bool) bool
+	SSEvent(name string, message any)
+	SSE(events <-chan render.SSEvent, keepAlive time.Duration)
+	LastEventID() string
 	Render(code int, r render.Render)
-	Writer() http.ResponseWriter
+	Negotiate(code int, config Negotiate)
+	NegotiateFormat(offered ...string) string
+	Writer() ResponseWriter
 }
 
 func NewContext() Context {
@@ -59,13 +153,20 @@ func NewContext() Context {
 
 type context struct {
 	// set during init
-	w                  http.ResponseWriter
+	writermem          responseWriter
+	w                  ResponseWriter
 	r                  *http.Request
 	useRawPath         bool
 	unescapePathValues bool
+	maxBodySize        int64
+	maxMultipartMemory int64
+	maxUploadSize      int64
+	maxUploadFileSize  int64
+	secureJSONPrefix   string
+	htmlRender         render.HTMLRender
 
 	// dynamic updated during processing
-	errs     error
+	errs     ErrorList
 	status   int
 	message  string
 	index    int8
@@ -78,6 +179,15 @@ type context struct {
 	// dynamic context updated during http request processing
 	pathSegments   pathsegment.PathSegments
 	pathSegmentIdx int
+	// bodyLimited is set when the size of the request body is limited
+	bodyLimited bool
+	// multipartErr is the error of parsing the multipart form
+	multipartErr error
+	// keys is the key/value store of the request, protected by mk
+	mk   sync.RWMutex
+	keys map[string]any
+	// finish are the functions called by Finish
+	finish []func()
 }
 
 /************ CONTEXT INIT ********/
@@ -90,18 +200,57 @@ type Config struct {
 	UseRawPath bool
 	// Used to unescape the urlraw path
 	UnescapePathValues bool
+	// MaxBodySize limits the size of the request body read when binding,
+	// a value <= 0 disables the limit.
+	MaxBodySize int64
+	// MaxMultipartMemory is the part of a multipart body kept in memory,
+	// the remainder of the files is stored in temporary files.
+	MaxMultipartMemory int64
+	// MaxUploadSize limits the size of a multipart body, when 0 MaxBodySize
+	// applies and a negative value disables the limit.
+	MaxUploadSize int64
+	// MaxUploadFileSize limits the size of every file in a multipart body,
+	// a value <= 0 disables the limit.
+	MaxUploadFileSize int64
+	// SecureJSONPrefix is the prefix of the SecureJSON responses,
+	// when empty DefaultSecureJSONPrefix is used.
+	SecureJSONPrefix string
+	// HTMLRender renders the HTML templates.
+	HTMLRender render.HTMLRender
 }
 
 func (c *context) Init(cfg *Config) {
-	c.w = cfg.Writer
+	c.writermem.reset(cfg.Writer)
+	c.w = &c.writermem
 	c.r = cfg.Request
 	c.params = cfg.Params
 	c.useRawPath = cfg.UseRawPath
+	c.unescapePathValues = cfg.UnescapePathValues
+	c.unescape = false
+	c.maxBodySize = cfg.MaxBodySize
+	c.maxMultipartMemory = cfg.MaxMultipartMemory
+	if c.maxMultipartMemory <= 0 {
+		c.maxMultipartMemory = binding.DefaultMemory
+	}
+	c.maxUploadSize = cfg.MaxUploadSize
+	c.maxUploadFileSize = cfg.MaxUploadFileSize
+	c.secureJSONPrefix = cfg.SecureJSONPrefix
+	if c.secureJSONPrefix == "" {
+		c.secureJSONPrefix = DefaultSecureJSONPrefix
+	}
+	c.htmlRender = cfg.HTMLRender
+	c.bodyLimited = false
+	c.multipartErr = nil
 
 	// need to reinitialize the
 	c.index = 0
 	c.handlers = New()
 	c.status = http.StatusOK
+	c.errs = c.errs[:0]
+	c.finish = c.finish[:0]
+	c.mk.Lock()
+	c.keys = nil
+	c.mk.Unlock()
 
 	c.urlPath = c.GetRequestPath()
 	if c.UseRawPath() && len(c.GetRawRequestPath()) > 0 {
@@ -110,6 +259,114 @@ func (c *context) Init(cfg *Config) {
 	}
 }
 
+/************ CONTEXT.CONTEXT **********/
+
+// Deadline returns the deadline of the request context.
+func (c *context) Deadline() (deadline time.Time, ok bool) {
+	if c.r == nil {
+		return
+	}
+	return c.r.Context().Deadline()
+}
+
+// Done returns the done channel of the request context, which is closed
+// when the client's connection closes or the request is finished.
+func (c *context) Done() <-chan struct{} {
+	if c.r == nil {
+		return nil
+	}
+	return c.r.Context().Done()
+}
+
+// Err returns the error of the request context.
+func (c *context) Err() error {
+	if c.r == nil {
+		return nil
+	}
+	return c.r.Context().Err()
+}
+
+// Value returns the Context itself for ContextKey, the value stored
+// with Set for string keys and otherwise delegates to the request context.
+func (c *context) Value(key any) any {
+	if key == ContextKey {
+		return c
+	}
+	if k, ok := key.(string); ok {
+		if v, exists := c.Get(k); exists {
+			return v
+		}
+	}
+	if c.r == nil {
+		return nil
+	}
+	return c.r.Context().Value(key)
+}
+
+// FromContext returns the Context stored in a context.Context
+// derived from it.
+func FromContext(ctx gocontext.Context) (Context, bool) {
+	c, ok := ctx.Value(ContextKey).(Context)
+	return c, ok
+}
+
+/************ CONTEXT VALUES ************/
+
+// WithValue replaces the request context with a context carrying the value.
+func (c *context) WithValue(key, val any) {
+	c.r = c.r.WithContext(gocontext.WithValue(c.r.Context(), key, val))
+}
+
+// WithCancel replaces the request context with a cancellable context derived from it.
+// The returned function must be called to release the resources.
+func (c *context) WithCancel() gocontext.CancelFunc {
+	ctx, cancel := gocontext.WithCancel(c.r.Context())
+	c.r = c.r.WithContext(ctx)
+	return cancel
+}
+
+// WithTimeout replaces the request context with a context derived from it
+// which is cancelled after the timeout.
+// The returned function must be called to release the resources.
+func (c *context) WithTimeout(timeout time.Duration) gocontext.CancelFunc {
+	ctx, cancel := gocontext.WithTimeout(c.r.Context(), timeout)
+	c.r = c.r.WithContext(ctx)
+	return cancel
+}
+
+// Copy returns a copy of the context that can be used outside the request scope,
+// e.g. in a goroutine, after the context is returned to the pool.
+// The copy cannot write the response nor run handlers, its writer fails with
+// ErrCopyWrite, so a render on the copy attaches that error to the copy.
+// It still observes the cancellation of the request context, which happens
+// when the request is finished.
+func (c *context) Copy() Context {
+	cp := &context{
+		w:                  copyWriter{},
+		r:                  c.r,
+		useRawPath:         c.useRawPath,
+		unescapePathValues: c.unescapePathValues,
+		secureJSONPrefix:   c.secureJSONPrefix,
+		htmlRender:         c.htmlRender,
+		status:             c.status,
+		message:            c.message,
+		index:              abortIndex,
+		handlers:           New(),
+		urlPath:            c.urlPath,
+		unescape:           c.unescape,
+		pathSegments:       pathsegment.Copy(c.pathSegments),
+		pathSegmentIdx:     c.pathSegmentIdx,
+		keys:               c.Keys(),
+	}
+	if c.params != nil {
+		cp.params = params.New(uint16(c.params.Size()))
+		for k, v := range c.params.List() {
+			cp.params.Add(params.Param{Key: k, Value: v})
+		}
+	}
+	return cp
+}
+
 /************ CONTEXT PROCESSING ********/
 
 func (c *context) GetStatus() int {
@@ -246,9 +503,65 @@ func (c *context) AbortWithStatus(code int) {
 	c.Abort()
 }
 
+// AbortWithError calls `AbortWithStatus()` and `Error()` internally.
+// This method stops the chain, writes the status code and pushes the specified error to `c.Errors`.
+// See Context.Error() for more details.
+func (c *context) AbortWithError(code int, err error) *Error {
+	c.AbortWithStatus(code)
+	return c.Error(err)
+}
+
+// OnFinish registers a function called by Finish once the response of the
+// request is complete, after the ErrorHandler of the server wrote it.
+// Middleware use it to observe the final status and size of the response.
+func (c *context) OnFinish(fn func()) {
+	c.finish = append(c.finish, fn)
+}
+
+// Finish calls the functions registered with OnFinish in the reverse order
+// of their registration. The server calls it once the response is complete.
+func (c *context) Finish() {
+	for i := len(c.finish) - 1; i >= 0; i-- {
+		c.finish[i]()
+	}
+	c.finish = c.finish[:0]
+}
+
+/************ ERRORS ********************/
+
+// Error attaches an error to the current context. The error is pushed to a list of errors.
+// It's a good idea to call Error for each error that occurred during the resolution of a request.
+// The errors are handed to the ErrorHandler of the server after the handlers are executed,
+// which translates them in a response.
+// Error panics if err is nil.
+func (c *context) Error(err error) *Error {
+	if err == nil {
+		panic("err is nil")
+	}
+
+	var parsedError *Error
+	ok := errors.As(err, &parsedError)
+	if !ok {
+		parsedError = &Error{
+			Err:  err,
+			Type: ErrorTypePrivate,
+		}
+	}
+
+	c.errs = append(c.errs, parsedError)
+	return parsedError
+}
+
+// Errors returns the errors attached to the context.
+func (c *context) Errors() ErrorList {
+	return c.errs
+}
+
 /************ RENDER RESPONSE ***********/
 
 // Status sets the HTTP response code.
+// The status is written with the response body, so it can be changed
+// until the body is written.
 func (c *context) Status(code int) {
 	c.w.WriteHeader(code)
 }
@@ -264,16 +577,233 @@ func (c *context) JSON(code int, obj any) {
 	c.Render(code, render.JSON{Data: obj})
 }
 
+// IndentedJSON serializes the given struct as pretty JSON (indented + endlines) into the response body.
+// It also sets the Content-Type as "application/json".
+// WARNING: we recommend using this only for development purposes since printing pretty JSON is
+// more CPU and bandwidth consuming. Use JSON() instead.
+func (c *context) IndentedJSON(code int, obj any) {
+	c.Render(code, render.IndentedJSON{Data: obj})
+}
+
+// SecureJSON serializes the given struct as Secure JSON into the response body.
+// Default prepends "while(1)," to response body if the given struct is array values.
+// It also sets the Content-Type as "application/json".
+func (c *context) SecureJSON(code int, obj any) {
+	c.Render(code, render.SecureJSON{Prefix: c.secureJSONPrefix, Data: obj})
+}
+
+// JSONP serializes the given struct as JSON into the response body.
+// It adds padding to response body to request data from a server residing in a different domain than the client.
+// It also sets the Content-Type as "application/javascript".
+func (c *context) JSONP(code int, obj any) {
+	callback := c.r.URL.Query().Get("callback")
+	if callback == "" {
+		c.Render(code, render.JSON{Data: obj})
+		return
+	}
+	c.Render(code, render.JsonpJSON{Callback: callback, Data: obj})
+}
+
+// AsciiJSON serializes the given struct as JSON into the response body with unicode to ASCII string.
+// It also sets the Content-Type as "application/json".
+func (c *context) AsciiJSON(code int, obj any) {
+	c.Render(code, render.AsciiJSON{Data: obj})
+}
+
+// PureJSON serializes the given struct as JSON into the response body.
+// PureJSON, unlike JSON, does not replace special html characters with their unicode entities.
+func (c *context) PureJSON(code int, obj any) {
+	c.Render(code, render.PureJSON{Data: obj})
+}
+
+// XML serializes the given struct as XML into the response body.
+// It also sets the Content-Type as "application/xml".
+func (c *context) XML(code int, obj any) {
+	c.Render(code, render.XML{Data: obj})
+}
+
+// YAML serializes the given struct as YAML into the response body.
+func (c *context) YAML(code int, obj any) {
+	c.Render(code, render.YAML{Data: obj})
+}
+
+// TOML serializes the given struct as TOML into the response body.
+func (c *context) TOML(code int, obj any) {
+	c.Render(code, render.TOML{Data: obj})
+}
+
+// ProtoBuf serializes the given struct as ProtoBuf into the response body.
+func (c *context) ProtoBuf(code int, obj any) {
+	c.Render(code, render.ProtoBuf{Data: obj})
+}
+
+// Data writes some data into the body stream and updates the HTTP code.
+func (c *context) Data(code int, contentType string, data []byte) {
+	c.Render(code, render.Data{
+		ContentType: contentType,
+		Data:        data,
+	})
+}
+
+// DataFromReader writes the specified reader into the body stream and updates the HTTP code.
+// With status 200 and an io.ReadSeeker, byte-range and conditional requests are answered.
+func (c *context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string) {
+	r := render.Reader{
+		Headers:       extraHeaders,
+		ContentType:   contentType,
+		ContentLength: contentLength,
+		Reader:        reader,
+	}
+	if code == http.StatusOK {
+		r.Request = c.r
+	}
+	c.Render(code, r)
+}
+
+// Redirect returns an HTTP redirect to the specific location.
+func (c *context) Redirect(code int, location string) {
+	c.Render(-1, render.Redirect{
+		Code:     code,
+		Location: location,
+		Request:  c.r,
+	})
+}
+
+// HTML renders the HTTP template specified by its file name.
+// It also updates the HTTP code and sets the Content-Type as "text/html".
+func (c *context) HTML(code int, name string, obj any) {
+	if c.htmlRender == nil {
+		c.AbortWithError(http.StatusInternalServerError, ErrNoHTMLRender).SetType(ErrorTypeRender)
+		return
+	}
+	c.Render(code, c.htmlRender.Instance(name, obj))
+}
+
+// StreamNDJSON streams the values of the source as newline delimited JSON,
+// flushing periodically, until the source ends or the client goes away.
+// It also sets the Content-Type as "application/x-ndjson".
+func (c *context) StreamNDJSON(code int, src render.Source) {
+	c.Render(code, render.NDJSON{Context: c.r.Context(), Source: src})
+}
+
+// StreamJSONArray streams the values of the source as a JSON array,
+// flushing periodically, until the source ends or the client goes away.
+// It also sets the Content-Type as "application/json".
+func (c *context) StreamJSONArray(code int, src render.Source) {
+	c.Render(code, render.JSONArray{Context: c.r.Context(), Source: src})
+}
+
+// Stream calls step until it returns false or the client goes away, flushing
+// the response after every step. It returns true when the client went away.
+func (c *context) Stream(step func(w io.Writer) bool) bool {
+	done := c.r.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c.w)
+			c.w.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
+// SSEvent writes a server-sent event into the body stream and flushes it.
+func (c *context) SSEvent(name string, message any) {
+	c.Render(-1, render.SSEvent{
+		Event: name,
+		Data:  message,
+	})
+	c.w.Flush()
+}
+
+// SSE writes the events received from the channel as server-sent events,
+// flushing after every event, until the channel is closed or the client
+// goes away. When keepAlive > 0 a comment is sent after keepAlive without
+// events, so proxies keep the connection open.
+func (c *context) SSE(events <-chan render.SSEvent, keepAlive time.Duration) {
+	render.SSEvent{}.WriteContentType(c.w)
+	c.w.Flush()
+
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	if keepAlive > 0 {
+		ticker = time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	done := c.r.Context().Done()
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case <-tick:
+			err = render.SSEComment(c.w, "keep-alive")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			err = event.Render(c.w)
+			if ticker != nil {
+				ticker.Reset(keepAlive)
+			}
+		}
+		if err != nil {
+			c.Error(err).SetType(ErrorTypeRender)
+			c.Abort()
+			return
+		}
+		c.w.Flush()
+	}
+}
+
+// LastEventID returns the id of the last server-sent event the client
+// received before it reconnected, used to resume the event stream.
+func (c *context) LastEventID() string {
+	return c.r.Header.Get("Last-Event-ID")
+}
+
 // Render writes the response headers and calls render.Render to render data.
 func (c *context) Render(code int, r render.Render) {
 	c.Status(code)
 
+	if !bodyAllowedForStatus(code) {
+		r.WriteContentType(c.w)
+		c.w.WriteHeaderNow()
+		return
+	}
+
 	if err := r.Render(c.w); err != nil {
-		c.errs = errors.Join(c.errs, err)
+		c.Error(err).SetType(ErrorTypeRender)
 		c.Abort()
 	}
 }
 
-func (c *context) Writer() http.ResponseWriter {
+// Writer returns the response writer of the request.
+func (c *context) Writer() ResponseWriter {
 	return c.w
 }
+
+func writeContentType(w http.ResponseWriter, value []string) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = value
+	}
+}
+
+// bodyAllowedForStatus is a copy of http.bodyAllowedForStatus non-exported function.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
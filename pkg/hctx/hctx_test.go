@@ -0,0 +1,764 @@
+package hctx
+
+import (
+	"bytes"
+	gocontext "context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/idproxy/httpserver/internal/pathsegment"
+	"github.com/idproxy/httpserver/pkg/binding"
+	"github.com/idproxy/httpserver/pkg/params"
+	"github.com/idproxy/httpserver/pkg/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestContext(w http.ResponseWriter, req *http.Request) Context {
+	c := NewContext()
+	c.Init(&Config{
+		Writer:  w,
+		Request: req,
+		Params:  params.New(16),
+	})
+	return c
+}
+
+func TestContextImplementsContext(t *testing.T) {
+	type key string
+	reqCtx, cancel := gocontext.WithCancel(gocontext.WithValue(gocontext.Background(), key("k"), "v"))
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx)
+	c := newTestContext(httptest.NewRecorder(), req)
+
+	var ctx gocontext.Context = c
+	assert.Equal(t, "v", ctx.Value(key("k")))
+	assert.NoError(t, ctx.Err())
+
+	fc, ok := FromContext(gocontext.WithValue(ctx, key("other"), 1))
+	assert.True(t, ok)
+	assert.Equal(t, c, fc)
+
+	cancel()
+	<-ctx.Done()
+	assert.ErrorIs(t, ctx.Err(), gocontext.Canceled)
+}
+
+func TestContextWithValueAndTimeout(t *testing.T) {
+	type key string
+	c := newTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	c.WithValue(key("user"), "alice")
+	assert.Equal(t, "alice", c.Value(key("user")))
+	assert.Equal(t, "alice", c.GetRequest().Context().Value(key("user")))
+
+	cancel := c.WithTimeout(time.Millisecond)
+	defer cancel()
+	_, ok := c.Deadline()
+	assert.True(t, ok)
+	<-c.Done()
+	assert.ErrorIs(t, c.Err(), gocontext.DeadlineExceeded)
+}
+
+func TestContextCopy(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/user/alice", nil))
+	c.GetParams().Add(params.Param{Key: "name", Value: "alice"})
+	ps, _ := pathsegment.New("/user/alice")
+	c.SetPathSegments(ps)
+
+	cp := c.Copy()
+	// the path segments of the original are updated in place
+	ps.Add(pathsegment.PathSegment{Value: "bob"})
+
+	// recycle the original context
+	c.Init(&Config{
+		Writer:  httptest.NewRecorder(),
+		Request: httptest.NewRequest(http.MethodGet, "/user/bob", nil),
+		Params:  params.New(16),
+	})
+	c.GetParams().Add(params.Param{Key: "name", Value: "bob"})
+
+	name, ok := cp.GetParams().Get("name")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", name)
+	assert.Equal(t, "/user/alice", cp.GetRequestPath())
+	assert.Equal(t, 3, cp.GetPathSegments().Size())
+	assert.Equal(t, "alice", cp.GetPathSegments().Get(2).Value)
+
+	// rendering on the copy fails instead of writing the response
+	cp.JSON(http.StatusOK, "alice")
+	assert.ErrorIs(t, cp.Errors().Last(), ErrCopyWrite)
+	assert.False(t, cp.Writer().Written())
+}
+
+func TestContextKeys(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	now := time.Now()
+
+	c.Set("user", "alice")
+	c.Set("admin", true)
+	c.Set("id", 42)
+	c.Set("login", now)
+	c.Set("ttl", time.Minute)
+
+	assert.Equal(t, "alice", c.GetString("user"))
+	assert.True(t, c.GetBool("admin"))
+	assert.Equal(t, 42, c.GetInt("id"))
+	assert.Equal(t, now, c.GetTime("login"))
+	assert.Equal(t, time.Minute, c.GetDuration("ttl"))
+	// wrong type returns the zero value
+	assert.Equal(t, "", c.GetString("id"))
+	assert.Equal(t, "alice", c.MustGet("user"))
+	assert.Panics(t, func() { c.MustGet("missing") })
+	assert.Equal(t, "alice", c.Value("user"))
+
+	id, ok := Get[int](c, "id")
+	assert.True(t, ok)
+	assert.Equal(t, 42, id)
+	_, ok = Get[string](c, "id")
+	assert.False(t, ok)
+
+	cp := c.Copy()
+	c.Init(&Config{
+		Writer:  httptest.NewRecorder(),
+		Request: httptest.NewRequest(http.MethodGet, "/", nil),
+		Params:  params.New(16),
+	})
+	_, exists := c.Get("user")
+	assert.False(t, exists)
+	assert.Equal(t, "alice", cp.GetString("user"))
+}
+
+func TestContextErrors(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, c.Errors())
+
+	c.Error(errors.New("first"))
+	c.Error(&Error{Err: errors.New("second"), Type: ErrorTypePublic})
+	c.Error(errors.New("third")).SetType(ErrorTypeBind).SetMeta("body")
+	assert.Panics(t, func() { c.Error(nil) })
+
+	assert.Len(t, c.Errors(), 3)
+	assert.Equal(t, []string{"first"}, c.Errors().ByType(ErrorTypePrivate).Errors())
+	assert.Equal(t, []string{"second"}, c.Errors().ByType(ErrorTypePublic).Errors())
+	assert.Equal(t, "third", c.Errors().Last().Error())
+	assert.Equal(t, "Error #01: first\n", c.Errors().ByType(ErrorTypePrivate).String())
+	assert.Equal(t, map[string]any{"error": "third", "meta": "body"}, c.Errors().Last().JSON())
+
+	c.Render(http.StatusOK, render.JSON{Data: make(chan int)})
+	assert.True(t, c.Errors().Last().IsType(ErrorTypeRender))
+}
+
+func TestContextResponseWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newTestContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, c.Writer().Written())
+	assert.Equal(t, http.StatusOK, c.Writer().Status())
+
+	c.AbortWithStatus(http.StatusUnauthorized)
+	assert.False(t, c.Writer().Written())
+	c.String(http.StatusForbidden, "denied")
+
+	assert.True(t, c.Writer().Written())
+	assert.Equal(t, http.StatusForbidden, c.Writer().Status())
+	assert.Equal(t, len("denied"), c.Writer().Size())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the status can no longer change once the body is written
+	c.Status(http.StatusInternalServerError)
+	assert.Equal(t, http.StatusForbidden, c.Writer().Status())
+}
+
+func TestContextRenderNoContent(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newTestContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	c.String(http.StatusNoContent, "ignored")
+
+	assert.True(t, c.Writer().Written())
+	assert.Equal(t, 0, c.Writer().Size())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestResponseWriterFlushAndHijack(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newTestContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	c.Writer().Flush()
+	assert.True(t, w.Flushed)
+	assert.True(t, c.Writer().Written())
+
+	_, _, err := c.Writer().Hijack()
+	assert.Error(t, err)
+	assert.Nil(t, c.Writer().Pusher())
+}
+
+func TestContextBind(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "alice"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	c := newTestContext(httptest.NewRecorder(), req)
+
+	var u user
+	assert.NoError(t, c.ShouldBind(&u))
+	assert.Equal(t, "alice", u.Name)
+}
+
+func TestContextBindErrors(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`name=alice`))
+	req.Header.Set("Content-Type", "application/unknown")
+	c := newTestContext(w, req)
+	assert.ErrorIs(t, c.Bind(&user{}), binding.ErrUnsupportedMediaType)
+	c.Writer().WriteHeaderNow()
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.True(t, c.Errors().Last().IsType(ErrorTypeBind))
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "a very long name"}`))
+	req.Header.Set("Content-Type", "application/json")
+	c = NewContext()
+	c.Init(&Config{Writer: w, Request: req, Params: params.New(16), MaxBodySize: 8})
+	assert.ErrorIs(t, c.Bind(&user{}), binding.ErrBodyTooLarge)
+	c.Writer().WriteHeaderNow()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestContextShouldBindURIQueryHeader(t *testing.T) {
+	var req struct {
+		Name   string        `uri:"name"`
+		Page   int           `query:"page,default=1"`
+		Limit  int           `query:"limit,default=20"`
+		Wait   time.Duration `query:"wait"`
+		Tenant string        `header:"X-Tenant"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/user/alice?limit=5&wait=2s", nil)
+	r.Header.Set("X-Tenant", "acme")
+	c := newTestContext(httptest.NewRecorder(), r)
+	c.GetParams().Add(params.Param{Key: "name", Value: "alice"})
+
+	assert.NoError(t, c.ShouldBindURI(&req))
+	assert.NoError(t, c.ShouldBindQuery(&req))
+	assert.NoError(t, c.ShouldBindHeader(&req))
+
+	assert.Equal(t, "alice", req.Name)
+	assert.Equal(t, 1, req.Page)
+	assert.Equal(t, 5, req.Limit)
+	assert.Equal(t, 2*time.Second, req.Wait)
+	assert.Equal(t, "acme", req.Tenant)
+}
+
+func TestContextBindValidation(t *testing.T) {
+	type user struct {
+		Name  string `json:"name" validate:"required,min=3"`
+		Email string `json:"email" validate:"required,email"`
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "al"}`))
+	req.Header.Set("Content-Type", "application/json")
+	c := newTestContext(w, req)
+
+	err := c.Bind(&user{})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "the request failed validation",
+		"errors": [
+			{"field": "name", "rule": "min", "param": "3", "message": "name must be at least 3 characters"},
+			{"field": "email", "rule": "required", "message": "email is required"}
+		]
+	}`, w.Body.String())
+}
+
+func newMultipartRequest(t *testing.T, values map[string]string, files map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range values {
+		assert.NoError(t, mw.WriteField(k, v))
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile(name, name+".txt")
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestContextMultipartForm(t *testing.T) {
+	req := newMultipartRequest(t, map[string]string{"title": "report"}, map[string]string{"file": "hello world"})
+	c := newTestContext(httptest.NewRecorder(), req)
+
+	fh, err := c.FormFile("file")
+	assert.NoError(t, err)
+	assert.Equal(t, "file.txt", fh.Filename)
+	_, err = c.FormFile("missing")
+	assert.ErrorIs(t, err, http.ErrMissingFile)
+
+	form, err := c.MultipartForm()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"report"}, form.Value["title"])
+
+	dst := filepath.Join(t.TempDir(), "uploads", "file.txt")
+	assert.NoError(t, c.SaveUploadedFile(fh, dst))
+	content, err := os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+
+	var upload struct {
+		Title string                `form:"title" validate:"required"`
+		File  *multipart.FileHeader `form:"file"`
+	}
+	assert.NoError(t, c.ShouldBind(&upload))
+	assert.Equal(t, "report", upload.Title)
+	assert.Equal(t, int64(len("hello world")), upload.File.Size)
+}
+
+func TestContextMultipartLimits(t *testing.T) {
+	req := newMultipartRequest(t, nil, map[string]string{"file": "hello world"})
+	c := NewContext()
+	c.Init(&Config{Writer: httptest.NewRecorder(), Request: req, Params: params.New(16), MaxUploadFileSize: 5})
+	_, err := c.FormFile("file")
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+	assert.ErrorIs(t, err, binding.ErrBodyTooLarge)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, bindErrorStatus(err))
+
+	req = newMultipartRequest(t, nil, map[string]string{"file": "hello world"})
+	c.Init(&Config{Writer: httptest.NewRecorder(), Request: req, Params: params.New(16), MaxUploadSize: 16})
+	_, err = c.MultipartForm()
+	assert.ErrorIs(t, err, binding.ErrBodyTooLarge)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, bindErrorStatus(err))
+}
+
+// countingReader counts the bytes read from the reader
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.n += n
+	return n, err
+}
+
+func TestContextMultipartFileLimitStreamed(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	assert.NoError(t, mw.WriteField("title", "report"))
+	fw, err := mw.CreateFormFile("large", "large.bin")
+	assert.NoError(t, err)
+	_, err = fw.Write(bytes.Repeat([]byte("x"), 1<<20))
+	assert.NoError(t, err)
+	fw, err = mw.CreateFormFile("small", "small.txt")
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte("hello"))
+	assert.NoError(t, err)
+	assert.NoError(t, mw.Close())
+	size := body.Len()
+
+	cr := &countingReader{Reader: body}
+	req := httptest.NewRequest(http.MethodPost, "/upload", cr)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	c := NewContext()
+	c.Init(&Config{Writer: httptest.NewRecorder(), Request: req, Params: params.New(16), MaxUploadFileSize: 5})
+
+	_, err = c.MultipartForm()
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+	// parsing stopped at the large file instead of reading the whole body
+	assert.Less(t, cr.n, size)
+
+	req = newMultipartRequest(t, map[string]string{"title": "report"}, map[string]string{"file": "hello"})
+	c.Init(&Config{Writer: httptest.NewRecorder(), Request: req, Params: params.New(16), MaxUploadFileSize: 5})
+	fh, err := c.FormFile("file")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), fh.Size)
+	assert.Equal(t, "report", req.PostFormValue("title"))
+}
+
+func TestContextEachPart(t *testing.T) {
+	req := newMultipartRequest(t, map[string]string{"title": "report"}, map[string]string{"file": "hello world"})
+	c := newTestContext(httptest.NewRecorder(), req)
+
+	parts := map[string]string{}
+	err := c.EachPart(func(p *Part) error {
+		b, err := io.ReadAll(p)
+		parts[p.FormName()] = string(b)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"title": "report", "file": "hello world"}, parts)
+
+	req = newMultipartRequest(t, map[string]string{"title": "a long title"}, map[string]string{"file": "hello world"})
+	c.Init(&Config{Writer: httptest.NewRecorder(), Request: req, Params: params.New(16), MaxUploadFileSize: 5})
+	err = c.EachPart(func(p *Part) error {
+		_, err := io.ReadAll(p)
+		return err
+	})
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+}
+
+type createUserRequest struct {
+	Org  string `uri:"org"`
+	Name string `json:"name" validate:"required"`
+}
+
+type createUserResponse struct {
+	Org  string `json:"org" xml:"org"`
+	Name string `json:"name" xml:"name"`
+}
+
+func (createUserResponse) StatusCode() int { return http.StatusCreated }
+
+func TestTyped(t *testing.T) {
+	h := Typed(func(c Context, req createUserRequest) (createUserResponse, error) {
+		if req.Name == "root" {
+			return createUserResponse{}, NewHTTPError(http.StatusConflict, "user exists")
+		}
+		if req.Name == "panic" {
+			return createUserResponse{}, errors.New("database unavailable")
+		}
+		return createUserResponse{Org: req.Org, Name: req.Name}, nil
+	})
+	serve := func(body, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/orgs/acme/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", accept)
+		c := newTestContext(w, req)
+		c.GetParams().Add(params.Param{Key: "org", Value: "acme"})
+		h(c)
+		c.Writer().WriteHeaderNow()
+		return w
+	}
+
+	w := serve(`{"name": "alice"}`, "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"org": "acme", "name": "alice"}`, w.Body.String())
+
+	w = serve(`{"name": "alice"}`, "text/html, application/xml;q=0.9")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<createUserResponse><org>acme</org><name>alice</name></createUserResponse>", w.Body.String())
+
+	w = serve(`{"name": "alice"}`, "text/html")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	w = serve(`{}`, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	w = serve(`{"name": "root"}`, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"error": "user exists"}`, w.Body.String())
+
+	w = serve(`{"name": "root"}`, "application/xml")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "<error>user exists</error>", w.Body.String())
+
+	// errors are rendered as JSON rather than with 406 Not Acceptable
+	w = serve(`{"name": "root"}`, "text/html")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"error": "user exists"}`, w.Body.String())
+
+	w = serve(`{"name": "panic"}`, "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "Internal Server Error"}`, w.Body.String())
+
+	w = serve(`{"name": "root"}`, "application/problem+json, application/json;q=0.5")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Conflict",
+		"status": 409,
+		"detail": "user exists",
+		"instance": "/orgs/acme/users"
+	}`, w.Body.String())
+
+	types, ok := TypesOf(New(h))
+	assert.True(t, ok)
+	assert.Equal(t, "createUserRequest", types.Request.Name())
+	assert.Equal(t, "createUserResponse", types.Response.Name())
+}
+
+func TestTypesOf(t *testing.T) {
+	h := Typed(func(c Context, req createUserRequest) (createUserResponse, error) {
+		return createUserResponse{}, nil
+	})
+	other := Typed(func(c Context, req struct{ Name string }) (struct{ Name string }, error) {
+		return struct{ Name string }{}, nil
+	})
+	middleware := New(func(c Context) {})
+
+	types, ok := TypesOf(middleware.Combine(New(h)))
+	assert.True(t, ok)
+	assert.Equal(t, reflect.TypeOf(createUserRequest{}), types.Request)
+	assert.Equal(t, reflect.TypeOf(createUserResponse{}), types.Response)
+
+	// every handler created by Typed has its own types
+	types, ok = TypesOf(New(other))
+	assert.True(t, ok)
+	assert.Equal(t, reflect.TypeOf(struct{ Name string }{}), types.Request)
+
+	// only the handler ending the chain has types
+	_, ok = TypesOf(New(h).Combine(middleware))
+	assert.False(t, ok)
+	_, ok = TypesOf(New())
+	assert.False(t, ok)
+	_, ok = TypesOf(New(func(c Context) {}))
+	assert.False(t, ok)
+}
+
+func TestContextNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", binding.MIMEJSON},
+		{"application/xml", binding.MIMEXML},
+		{"application/json;q=0.5, application/xml;q=0.8", binding.MIMEXML},
+		{"application/xml;q=0, */*;q=0.1", binding.MIMEJSON},
+		{"text/html, application/*;q=0.9", binding.MIMEJSON},
+		{"application/yaml;q=1.0, application/json", binding.MIMEJSON},
+		{"text/html", ""},
+		// the most specific range determines the quality of a format
+		{"application/json;q=0, application/*", binding.MIMEXML},
+		{"application/*;q=0.2, application/xml;q=0.1, */*", binding.MIMEJSON},
+		{"*/*;q=0.5, application/xml", binding.MIMEXML},
+		{"application/*;q=0", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		c := newTestContext(httptest.NewRecorder(), req)
+		assert.Equal(t, tt.want, c.NegotiateFormat(binding.MIMEJSON, binding.MIMEXML), tt.accept)
+	}
+}
+
+func TestContextNegotiate(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/x-yaml")
+	c := newTestContext(w, req)
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEYAML},
+		Data:     map[string]string{"name": "alice"},
+		JSONData: map[string]string{"format": "json"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "name: alice\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req.Header.Set("Accept", "text/html")
+	c = newTestContext(w, req)
+	c.Negotiate(http.StatusOK, Negotiate{Offered: []string{binding.MIMEJSON}, Data: "data"})
+	c.Writer().WriteHeaderNow()
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.ErrorIs(t, c.Errors().Last(), ErrNotAcceptable)
+}
+
+func TestContextRenderMethods(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		render      func(c Context)
+		contentType string
+		body        string
+	}{
+		{"IndentedJSON", "/", func(c Context) { c.IndentedJSON(http.StatusOK, map[string]int{"a": 1}) },
+			"application/json; charset=utf-8", "{\n    \"a\": 1\n}"},
+		{"SecureJSON", "/", func(c Context) { c.SecureJSON(http.StatusOK, []int{1}) },
+			"application/json; charset=utf-8", "while(1);[1]"},
+		{"JSONP", "/?callback=cb", func(c Context) { c.JSONP(http.StatusOK, map[string]int{"a": 1}) },
+			"application/javascript; charset=utf-8", `cb({"a":1});`},
+		{"JSONPWithoutCallback", "/", func(c Context) { c.JSONP(http.StatusOK, map[string]int{"a": 1}) },
+			"application/json; charset=utf-8", `{"a":1}`},
+		{"AsciiJSON", "/", func(c Context) { c.AsciiJSON(http.StatusOK, "é") },
+			"application/json", `"\u00e9"`},
+		{"PureJSON", "/", func(c Context) { c.PureJSON(http.StatusOK, "<b>") },
+			"application/json; charset=utf-8", "\"<b>\"\n"},
+		{"XML", "/", func(c Context) { c.XML(http.StatusOK, createUserResponse{Name: "alice"}) },
+			"application/xml; charset=utf-8", "<createUserResponse><org></org><name>alice</name></createUserResponse>"},
+		{"YAML", "/", func(c Context) { c.YAML(http.StatusOK, map[string]int{"a": 1}) },
+			"application/x-yaml; charset=utf-8", "a: 1\n"},
+		{"TOML", "/", func(c Context) { c.TOML(http.StatusOK, map[string]int{"a": 1}) },
+			"application/toml; charset=utf-8", "a = 1\n"},
+		{"Data", "/", func(c Context) { c.Data(http.StatusOK, "text/csv", []byte("a,b")) },
+			"text/csv", "a,b"},
+		{"DataFromReader", "/", func(c Context) {
+			c.DataFromReader(http.StatusOK, 3, "text/plain", strings.NewReader("abc"), nil)
+		}, "text/plain", "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c := newTestContext(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			tt.render(c)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
+
+func TestContextRedirect(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newTestContext(w, httptest.NewRequest(http.MethodGet, "/old", nil))
+	c.Redirect(http.StatusMovedPermanently, "/new")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/new", w.Header().Get("Location"))
+}
+
+func TestContextSecureJSONPrefix(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext()
+	c.Init(&Config{
+		Writer:           w,
+		Request:          httptest.NewRequest(http.MethodGet, "/", nil),
+		Params:           params.New(16),
+		SecureJSONPrefix: ")]}',\n",
+	})
+	c.SecureJSON(http.StatusOK, []int{1})
+	assert.Equal(t, ")]}',\n[1]", w.Body.String())
+}
+
+func TestContextHTMLWithoutRender(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newTestContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	c.HTML(http.StatusOK, "index.tmpl", nil)
+	c.Writer().WriteHeaderNow()
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.ErrorIs(t, c.Errors().Last(), ErrNoHTMLRender)
+}
+
+func TestContextFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "data.txt")
+	assert.NoError(t, os.WriteFile(name, []byte("0123456789"), 0o600))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(name, modTime, modTime))
+
+	serve := func(header http.Header, handler func(c Context)) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		c := newTestContext(w, req)
+		handler(c)
+		c.Writer().WriteHeaderNow()
+		return w
+	}
+	file := func(c Context) { c.File(name) }
+
+	w := serve(nil, file)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	w = serve(http.Header{"Range": {"bytes=2-4"}}, file)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "234", w.Body.String())
+	assert.Equal(t, "bytes 2-4/10", w.Header().Get("Content-Range"))
+
+	w = serve(http.Header{"Range": {"bytes=0-1,8-"}}, file)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "multipart/byteranges; boundary="))
+	assert.Contains(t, w.Body.String(), "Content-Range: bytes 8-9/10")
+
+	w = serve(http.Header{"Range": {"bytes=20-"}}, file)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+
+	w = serve(http.Header{"If-None-Match": {etag}}, file)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = serve(http.Header{"If-Modified-Since": {modTime.Format(http.TimeFormat)}}, file)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = serve(nil, func(c Context) { c.FileAttachment(name, "résumé.txt") })
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="r_sum_.txt"; filename*=UTF-8''r%C3%A9sum%C3%A9.txt`, w.Header().Get("Content-Disposition"))
+
+	w = serve(nil, func(c Context) { c.File(filepath.Join(dir, "missing.txt")) })
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(nil, func(c Context) { c.File(dir) })
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestContextFileFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"static/app.js": {Data: []byte("alert(1)")},
+	}
+	for target, code := range map[string]int{
+		"static/app.js":           http.StatusOK,
+		"/static/app.js":          http.StatusOK,
+		"../../static/app.js":     http.StatusOK,
+		"static":                  http.StatusNotFound,
+		"static/missing.js":       http.StatusNotFound,
+		"static/../../etc/passwd": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		c := newTestContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		c.FileFromFS(target, fsys)
+		c.Writer().WriteHeaderNow()
+		assert.Equal(t, code, w.Code, target)
+		if code == http.StatusOK {
+			assert.Equal(t, "alert(1)", w.Body.String())
+			assert.Equal(t, "text/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Empty(t, w.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestContextDataFromReaderRange(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=1-")
+	c := newTestContext(w, req)
+	c.DataFromReader(http.StatusOK, 3, "text/plain", strings.NewReader("abc"), map[string]string{"X-Extra": "1"})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bc", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Extra"))
+
+	// other status codes are written as is
+	w = httptest.NewRecorder()
+	c = newTestContext(w, req)
+	extra := map[string]string{"X-Extra": "1"}
+	c.DataFromReader(http.StatusCreated, 3, "text/plain", strings.NewReader("abc"), extra)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "abc", w.Body.String())
+	assert.Equal(t, "3", w.Header().Get("Content-Length"))
+	// the headers of the caller are not modified
+	assert.Equal(t, map[string]string{"X-Extra": "1"}, extra)
+}
@@ -0,0 +1,134 @@
+package hctx
+
+import (
+	"fmt"
+	"time"
+)
+
+// Set stores a key/value pair for the scope of the request.
+// It lazily initializes the key store.
+func (c *context) Set(key string, value any) {
+	c.mk.Lock()
+	defer c.mk.Unlock()
+	if c.keys == nil {
+		c.keys = make(map[string]any)
+	}
+	c.keys[key] = value
+}
+
+// Get returns the value for the given key and whether it exists.
+func (c *context) Get(key string) (value any, exists bool) {
+	c.mk.RLock()
+	defer c.mk.RUnlock()
+	value, exists = c.keys[key]
+	return
+}
+
+// MustGet returns the value for the given key if it exists, otherwise it panics.
+func (c *context) MustGet(key string) any {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic(fmt.Sprintf("key %q does not exist", key))
+}
+
+// Keys returns a copy of the key/value pairs stored for the request.
+func (c *context) Keys() map[string]any {
+	c.mk.RLock()
+	defer c.mk.RUnlock()
+	if c.keys == nil {
+		return nil
+	}
+	keys := make(map[string]any, len(c.keys))
+	for k, v := range c.keys {
+		keys[k] = v
+	}
+	return keys
+}
+
+// GetString returns the value associated with the key as a string.
+func (c *context) GetString(key string) string {
+	v, _ := Get[string](c, key)
+	return v
+}
+
+// GetBool returns the value associated with the key as a boolean.
+func (c *context) GetBool(key string) bool {
+	v, _ := Get[bool](c, key)
+	return v
+}
+
+// GetInt returns the value associated with the key as an integer.
+func (c *context) GetInt(key string) int {
+	v, _ := Get[int](c, key)
+	return v
+}
+
+// GetInt64 returns the value associated with the key as an integer.
+func (c *context) GetInt64(key string) int64 {
+	v, _ := Get[int64](c, key)
+	return v
+}
+
+// GetUint returns the value associated with the key as an unsigned integer.
+func (c *context) GetUint(key string) uint {
+	v, _ := Get[uint](c, key)
+	return v
+}
+
+// GetUint64 returns the value associated with the key as an unsigned integer.
+func (c *context) GetUint64(key string) uint64 {
+	v, _ := Get[uint64](c, key)
+	return v
+}
+
+// GetFloat64 returns the value associated with the key as a float64.
+func (c *context) GetFloat64(key string) float64 {
+	v, _ := Get[float64](c, key)
+	return v
+}
+
+// GetTime returns the value associated with the key as time.
+func (c *context) GetTime(key string) time.Time {
+	v, _ := Get[time.Time](c, key)
+	return v
+}
+
+// GetDuration returns the value associated with the key as a duration.
+func (c *context) GetDuration(key string) time.Duration {
+	v, _ := Get[time.Duration](c, key)
+	return v
+}
+
+// GetStringSlice returns the value associated with the key as a slice of strings.
+func (c *context) GetStringSlice(key string) []string {
+	v, _ := Get[[]string](c, key)
+	return v
+}
+
+// GetStringMap returns the value associated with the key as a map of interfaces.
+func (c *context) GetStringMap(key string) map[string]any {
+	v, _ := Get[map[string]any](c, key)
+	return v
+}
+
+// GetStringMapString returns the value associated with the key as a map of strings.
+func (c *context) GetStringMapString(key string) map[string]string {
+	v, _ := Get[map[string]string](c, key)
+	return v
+}
+
+// Get returns the value stored for the key in the context when it
+// exists and is of type T.
+func Get[T any](c Context, key string) (T, bool) {
+	var zero T
+	value, exists := c.Get(key)
+	if !exists {
+		return zero, false
+	}
+	v, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return v, true
+}
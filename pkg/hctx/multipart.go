@@ -0,0 +1,212 @@
+package hctx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/idproxy/httpserver/pkg/binding"
+)
+
+// ErrFileTooLarge is returned when an uploaded file exceeds the maximum upload file size.
+var ErrFileTooLarge = errors.New("multipart: file too large")
+
+// Part is a part of a streamed multipart body.
+// Reading a file part fails with ErrFileTooLarge once it exceeds the maximum
+// upload file size.
+type Part struct {
+	*multipart.Part
+	limit int64
+	read  int64
+}
+
+// Read reads the body of the part.
+func (p *Part) Read(b []byte) (int, error) {
+	n, err := p.Part.Read(b)
+	p.read += int64(n)
+	if p.limit > 0 && p.read > p.limit && p.FileName() != "" {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+// MultipartForm parses the multipart form, including file uploads.
+// Files up to the maximum multipart memory are kept in memory, the remainder
+// is stored in temporary files which are removed when the request is finished.
+// The body is limited to the maximum upload size and every file to the maximum
+// upload file size, parsing stops at the first file exceeding it.
+func (c *context) MultipartForm() (*multipart.Form, error) {
+	if c.multipartErr != nil {
+		return nil, c.multipartErr
+	}
+	if c.r.MultipartForm != nil {
+		return c.r.MultipartForm, nil
+	}
+	c.limitBody(c.uploadLimit())
+	var err error
+	if c.maxUploadFileSize > 0 {
+		err = c.parseLimitedMultipartForm()
+	} else {
+		err = c.r.ParseMultipartForm(c.maxMultipartMemory)
+	}
+	if err != nil {
+		c.multipartErr = multipartError(err)
+		return nil, c.multipartErr
+	}
+	return c.r.MultipartForm, nil
+}
+
+// parseLimitedMultipartForm parses the multipart form like
+// http.Request.ParseMultipartForm, enforcing the maximum upload file size
+// while the parts are streamed. The parts are copied through a pipe to
+// multipart.Reader.ReadForm, the copy of a file part exceeding the size
+// fails the form before the remainder of the body is read.
+func (c *context) parseLimitedMultipartForm() error {
+	if c.r.Form == nil {
+		if err := c.r.ParseForm(); err != nil {
+			return err
+		}
+	}
+	mr, err := c.r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(copyParts(mw, mr, c.maxUploadFileSize))
+	}()
+	form, err := multipart.NewReader(pr, mw.Boundary()).ReadForm(c.maxMultipartMemory)
+	// unblock the copy when ReadForm failed before the end of the body
+	pr.CloseWithError(err)
+	if err != nil {
+		return err
+	}
+	if c.r.PostForm == nil {
+		c.r.PostForm = make(url.Values)
+	}
+	for k, v := range form.Value {
+		c.r.Form[k] = append(c.r.Form[k], v...)
+		c.r.PostForm[k] = append(c.r.PostForm[k], v...)
+	}
+	c.r.MultipartForm = form
+	return nil
+}
+
+// copyParts copies the parts of mr to mw, failing with ErrFileTooLarge at
+// the first file part larger than limit.
+func copyParts(mw *multipart.Writer, mr *multipart.Reader, limit int64) error {
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return mw.Close()
+		}
+		if err != nil {
+			return err
+		}
+		w, err := mw.CreatePart(p.Header)
+		if err != nil {
+			return err
+		}
+		var src io.Reader = p
+		if p.FileName() != "" {
+			// read one byte more than the limit to detect larger files
+			src = io.LimitReader(p, limit+1)
+		}
+		n, err := io.Copy(w, src)
+		if err != nil {
+			return err
+		}
+		if p.FileName() != "" && n > limit {
+			return ErrFileTooLarge
+		}
+	}
+}
+
+// FormFile returns the first file for the provided form key.
+func (c *context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	if fhs := form.File[name]; len(fhs) > 0 {
+		return fhs[0], nil
+	}
+	return nil, http.ErrMissingFile
+}
+
+// SaveUploadedFile uploads the form file to specific dst.
+func (c *context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// EachPart streams the parts of the multipart body without buffering them,
+// calling fn for every part until all parts are read or fn returns an error.
+// The body is limited to the maximum upload size and every file part to
+// the maximum upload file size.
+// EachPart cannot be combined with MultipartForm, FormFile or multipart binding.
+func (c *context) EachPart(fn func(p *Part) error) error {
+	c.limitBody(c.uploadLimit())
+	mr, err := c.r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return multipartError(err)
+		}
+		err = fn(&Part{Part: p, limit: c.maxUploadFileSize})
+		p.Close()
+		if err != nil {
+			return multipartError(err)
+		}
+	}
+}
+
+// uploadLimit returns the size limit of a multipart body
+func (c *context) uploadLimit() int64 {
+	if c.maxUploadSize != 0 {
+		return c.maxUploadSize
+	}
+	return c.maxBodySize
+}
+
+// multipartError translates the errors of reading a body exceeding the
+// size limits in a binding.Error
+func multipartError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &binding.Error{Binding: binding.FormMultipart.Name(), Err: binding.ErrBodyTooLarge}
+	case errors.Is(err, ErrFileTooLarge):
+		// a file over its cap is too large like a body over the total cap
+		return &binding.Error{Binding: binding.FormMultipart.Name(), Err: fmt.Errorf("%w: %w", binding.ErrBodyTooLarge, ErrFileTooLarge)}
+	}
+	return err
+}
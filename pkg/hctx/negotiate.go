@@ -0,0 +1,160 @@
+package hctx
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/idproxy/httpserver/pkg/binding"
+	"github.com/idproxy/httpserver/pkg/render"
+)
+
+// ErrNotAcceptable is used when none of the offered formats is accepted by the client.
+var ErrNotAcceptable = errors.New("none of the offered formats is accepted")
+
+// Negotiate contains all negotiation data. The data of the negotiated format
+// is used when set, otherwise Data.
+type Negotiate struct {
+	Offered      []string
+	Data         any
+	JSONData     any
+	XMLData      any
+	YAMLData     any
+	TOMLData     any
+	ProtoBufData any
+	MsgPackData  any
+	CBORData     any
+}
+
+// mediaRange is a media range of the Accept header with its quality
+type mediaRange struct {
+	value string
+	q     float64
+}
+
+// Negotiate renders the data in the format, out of the offered formats, the
+// client prefers according to the Accept header. When none of the offered
+// formats is accepted the request is aborted with 406 Not Acceptable.
+func (c *context) Negotiate(code int, config Negotiate) {
+	format := c.NegotiateFormat(config.Offered...)
+	r, ok := negotiateRender(format, config)
+	if !ok {
+		c.AbortWithError(http.StatusNotAcceptable, ErrNotAcceptable)
+		return
+	}
+	c.Render(code, r)
+}
+
+// NegotiateFormat returns the offered format the client prefers according to
+// the Accept header, the first offered format when the request has no Accept
+// header and "" when none of the offered formats is accepted.
+// Every offered format gets the quality of the most specific media range
+// matching it (RFC 9110 section 12.5.1), a quality of 0 means not acceptable.
+// Formats with a higher quality are preferred, on equal quality the order of
+// the Accept header and then of the offered formats is used.
+func (c *context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	accept := strings.Join(c.r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return offered[0]
+	}
+	ranges := parseAccept(accept)
+	best, bestQ, bestIdx := "", 0.0, 0
+	for _, offer := range offered {
+		q, idx := acceptQuality(ranges, offer)
+		if q > bestQ || (q > 0 && q == bestQ && idx < bestIdx) {
+			best, bestQ, bestIdx = offer, q, idx
+		}
+	}
+	return best
+}
+
+// parseAccept returns the media ranges of the accept header in their order,
+// including the ranges that are not acceptable (q=0).
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0, 4)
+	for _, part := range strings.Split(accept, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(k) != "q" {
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || f < 0 || f > 1 {
+				f = 0
+			}
+			q = f
+		}
+		ranges = append(ranges, mediaRange{value: value, q: q})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality and the index of the most specific media
+// range matching the offered media type, a quality of 0 when none matches.
+func acceptQuality(ranges []mediaRange, offer string) (float64, int) {
+	offer, _, _ = strings.Cut(offer, ";")
+	offer = strings.ToLower(strings.TrimSpace(offer))
+	q, idx, specificity := 0.0, 0, -1
+	for i, r := range ranges {
+		s := matchMediaRange(r.value, offer)
+		if s > specificity {
+			q, idx, specificity = r.q, i, s
+		}
+	}
+	return q, idx
+}
+
+// matchMediaRange returns the specificity of the accepted media range for
+// the offered media type: 2 for the media type, 1 for type/* and 0 for */*.
+// It returns -1 when the offered media type is not in the range.
+func matchMediaRange(accepted, offer string) int {
+	switch {
+	case accepted == offer:
+		return 2
+	case accepted == "*/*":
+		return 0
+	}
+	typ, ok := strings.CutSuffix(accepted, "/*")
+	if ok && strings.HasPrefix(offer, typ+"/") {
+		return 1
+	}
+	return -1
+}
+
+// negotiateRender returns the renderer of the negotiated format
+func negotiateRender(format string, config Negotiate) (render.Render, bool) {
+	data := func(d any) any {
+		if d != nil {
+			return d
+		}
+		return config.Data
+	}
+	switch format {
+	case binding.MIMEJSON:
+		return render.JSON{Data: data(config.JSONData)}, true
+	case binding.MIMEXML, binding.MIMEXML2:
+		return render.XML{Data: data(config.XMLData)}, true
+	case binding.MIMEYAML, binding.MIMEYAML2:
+		return render.YAML{Data: data(config.YAMLData)}, true
+	case binding.MIMETOML:
+		return render.TOML{Data: data(config.TOMLData)}, true
+	case binding.MIMEPROTOBUF:
+		return render.ProtoBuf{Data: data(config.ProtoBufData)}, true
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		return msgpackRender(data(config.MsgPackData))
+	case binding.MIMECBOR:
+		return cborRender(data(config.CBORData))
+	default:
+		return nil, false
+	}
+}
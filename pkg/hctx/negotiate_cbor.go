@@ -0,0 +1,15 @@
+//go:build !nocbor
+
+package hctx
+
+import (
+	"github.com/idproxy/httpserver/pkg/binding"
+	"github.com/idproxy/httpserver/pkg/render"
+)
+
+// cborOffered are the formats offered by typed handlers when built with support
+var cborOffered = []string{binding.MIMECBOR}
+
+func cborRender(data any) (render.Render, bool) {
+	return render.CBOR{Data: data}, true
+}
@@ -0,0 +1,42 @@
+//go:build !nocbor
+
+package hctx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/idproxy/httpserver/pkg/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestContextNegotiateCBOR(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json;q=0.5, application/cbor")
+	c := newTestContext(w, req)
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{binding.MIMEJSON, binding.MIMECBOR},
+		Data:    map[string]string{"name": "alice"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/cbor", w.Header().Get("Content-Type"))
+
+	var got map[string]string
+	assert.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), new(codec.CborHandle)).Decode(&got))
+	assert.Equal(t, map[string]string{"name": "alice"}, got)
+}
+
+func TestTypedCBOR(t *testing.T) {
+	h := Typed(func(c Context, req struct{}) (map[string]string, error) {
+		return map[string]string{"name": "alice"}, nil
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/cbor")
+	h(newTestContext(w, req))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/cbor", w.Header().Get("Content-Type"))
+}
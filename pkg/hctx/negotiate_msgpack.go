@@ -0,0 +1,15 @@
+//go:build !nomsgpack
+
+package hctx
+
+import (
+	"github.com/idproxy/httpserver/pkg/binding"
+	"github.com/idproxy/httpserver/pkg/render"
+)
+
+// msgpackOffered are the formats offered by typed handlers when built with support
+var msgpackOffered = []string{binding.MIMEMSGPACK, binding.MIMEMSGPACK2}
+
+func msgpackRender(data any) (render.Render, bool) {
+	return render.MsgPack{Data: data}, true
+}
@@ -0,0 +1,12 @@
+//go:build nocbor
+
+package hctx
+
+import "github.com/idproxy/httpserver/pkg/render"
+
+// cborOffered is empty, the format is not supported in this build
+var cborOffered []string
+
+func cborRender(data any) (render.Render, bool) {
+	return nil, false
+}
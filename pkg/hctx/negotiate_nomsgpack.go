@@ -0,0 +1,12 @@
+//go:build nomsgpack
+
+package hctx
+
+import "github.com/idproxy/httpserver/pkg/render"
+
+// msgpackOffered is empty, the format is not supported in this build
+var msgpackOffered []string
+
+func msgpackRender(data any) (render.Render, bool) {
+	return nil, false
+}
@@ -0,0 +1,187 @@
+package hctx
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"github.com/idproxy/httpserver/pkg/binding"
+	"github.com/idproxy/httpserver/pkg/render"
+)
+
+// StatusCoder is implemented by errors and responses that determine
+// the http status code of the response.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// HTTPError is an error with the http status code of the response.
+// The message of an HTTPError is exposed to the client.
+type HTTPError struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+// NewHTTPError returns an HTTPError with the status code and the message exposed to the client.
+func NewHTTPError(code int, message string) *HTTPError {
+	return &HTTPError{Code: code, Message: message}
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Code)
+	}
+	return e.Message
+}
+
+// StatusCode returns the http status code of the error.
+func (e *HTTPError) StatusCode() int {
+	return e.Code
+}
+
+// Unwrap returns the wrapped error, to allow interoperability with errors.Is(), errors.As() and errors.Unwrap()
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// HandlerTypes are the request and response types of a typed handler.
+type HandlerTypes struct {
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// typedHandlers are the types of the handlers created by Typed, by the
+// closure of the handler, see TypesOf
+var typedHandlers sync.Map
+
+// handlerKey returns the pointer of the closure of the handler, which
+// identifies the handler created by a call of Typed. The code pointer
+// returned by reflect is shared by the instances of the generic closure.
+func handlerKey(h HandlerFunc) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&h))
+}
+
+// TypesOf returns the request and response types of the handler ending the
+// chain, when it was created by Typed.
+func TypesOf(h HandlerChain) (HandlerTypes, bool) {
+	if h == nil || h.Size() == 0 {
+		return HandlerTypes{}, false
+	}
+	types, ok := typedHandlers.Load(handlerKey(h.Get(h.Size() - 1)))
+	if !ok {
+		return HandlerTypes{}, false
+	}
+	return types.(HandlerTypes), true
+}
+
+// typedOffered are the formats typed handlers render the response in
+var typedOffered = append([]string{
+	binding.MIMEJSON, binding.MIMEXML, binding.MIMEXML2, binding.MIMEYAML, binding.MIMEYAML2, binding.MIMETOML,
+}, append(msgpackOffered, cborOffered...)...)
+
+// Typed returns a handler that binds the path parameters, query string,
+// headers and body of the request into Req, validates it, calls fn and renders
+// the returned Resp in the format negotiated with the client, JSON by default.
+// Binding failures abort the request like Bind. A returned error aborts the
+// request with the status of the error when it implements StatusCoder and
+// 500 otherwise. The status of the response is 200 unless Resp implements StatusCoder.
+//
+// The request and response types of the handler are listed with the route
+// it ends, see TypesOf.
+func Typed[Req, Resp any](fn func(Context, Req) (Resp, error)) HandlerFunc {
+	h := HandlerFunc(func(c Context) {
+		var req Req
+		if err := c.ShouldBindRequest(&req); err != nil {
+			abortWithBindError(c, err)
+			return
+		}
+		resp, err := fn(c, req)
+		if err != nil {
+			abortWithHandlerError(c, err)
+			return
+		}
+		if c.Writer().Written() {
+			return
+		}
+		code := http.StatusOK
+		if sc, ok := any(resp).(StatusCoder); ok {
+			code = sc.StatusCode()
+		}
+		c.Negotiate(code, Negotiate{Offered: typedOffered, Data: resp})
+	})
+	typedHandlers.Store(handlerKey(h), HandlerTypes{
+		Request:  reflect.TypeOf((*Req)(nil)).Elem(),
+		Response: reflect.TypeOf((*Resp)(nil)).Elem(),
+	})
+	return h
+}
+
+// ShouldBindRequest binds the path parameters, query string, headers and the
+// body of the request into obj and validates the result once.
+// The sources are mapped on the fields with the `uri`, `query`, `header`
+// and body tags. The body is only bound when the request has one.
+func (c *context) ShouldBindRequest(obj any) error {
+	if err := c.decodeURI(obj); err != nil {
+		return err
+	}
+	if err := c.decodeWith(obj, binding.Query); err != nil {
+		return err
+	}
+	if err := c.decodeWith(obj, binding.Header); err != nil {
+		return err
+	}
+	if hasBody(c.r) {
+		if err := c.decodeWith(obj, binding.Default(c.GetMethod(), c.ContentType())); err != nil {
+			return err
+		}
+	}
+	return binding.Validate(obj)
+}
+
+func hasBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+	return r.ContentLength != 0 || len(r.TransferEncoding) > 0
+}
+
+// handlerError is the body of the error responses of typed handlers
+type handlerError struct {
+	XMLName xml.Name `json:"-" xml:"error" yaml:"-" toml:"-" codec:"-"`
+	Error   string   `json:"error" xml:",chardata" yaml:"error" toml:"error" codec:"error"`
+}
+
+// abortWithHandlerError aborts the request with the status of the error and
+// renders it in the format negotiated with the client: as problem details when
+// the client prefers application/problem+json and as JSON when none of the
+// formats is accepted. The message of errors without a status is not exposed
+// to the client.
+func abortWithHandlerError(c Context, err error) {
+	code := http.StatusInternalServerError
+	body := handlerError{Error: http.StatusText(code)}
+	typ := ErrorTypePrivate
+	var sc StatusCoder
+	if errors.As(err, &sc) {
+		code = sc.StatusCode()
+		body.Error = err.Error()
+		typ = ErrorTypePublic
+	}
+	c.AbortWithError(code, err).SetType(typ)
+	// problem details are only rendered when preferred over the other formats
+	offered := append(typedOffered[:len(typedOffered):len(typedOffered)], render.ProblemContentType)
+	switch c.NegotiateFormat(offered...) {
+	case render.ProblemContentType:
+		problem := render.NewProblem(code, body.Error)
+		problem.Instance = c.GetRequestPath()
+		c.Render(code, problem)
+	case "":
+		c.Render(code, render.JSON{Data: body})
+	default:
+		c.Negotiate(code, Negotiate{Offered: typedOffered, Data: body})
+	}
+}
@@ -61,39 +61,41 @@ func LoggerWithConfig(conf LoggerConfig) hctx.HandlerFunc {
 		}
 	}
 
-	return func(hctx hctx.Context) {
+	return func(c hctx.Context) {
 		// Start timer
 		start := time.Now()
-		path := hctx.GetRequestPath()
-		raw := hctx.GetRawQuery()
+		path := c.GetRequestPath()
+		raw := c.GetRawQuery()
 
 		// Log only when path is not being skipped
 		if _, ok := skip[path]; !ok {
-			param := LogFormatterParams{
-				Request: hctx.GetRequest(),
-				isTerm:  isTerm,
-				//Keys:    gctx.Keys,
-			}
-
-			// Stop timer
-			param.TimeStamp = time.Now()
-			param.Latency = param.TimeStamp.Sub(start)
-
-			param.ClientIP = hctx.ClientIP()
-			param.Method = hctx.GetMethod()
-			param.StatusCode = hctx.GetStatus()
-			//param.ErrorMessage = hctx.
-			//param.ErrorMessage = gctx.Errors.ByType(ErrorTypePrivate).String()
-
-			//param.BodySize = gctx.Writer.Size()
-
-			if raw != "" {
-				path = path + "?" + raw
-			}
-
-			param.Path = path
-
-			fmt.Fprint(out, formatter(param))
+			// log once the response is complete, after the remaining handlers
+			// and the ErrorHandler of the server wrote it
+			c.OnFinish(func() {
+				param := LogFormatterParams{
+					Request: c.GetRequest(),
+					isTerm:  isTerm,
+					Keys:    c.Keys(),
+				}
+
+				// Stop timer
+				param.TimeStamp = time.Now()
+				param.Latency = param.TimeStamp.Sub(start)
+
+				param.ClientIP = c.ClientIP()
+				param.Method = c.GetMethod()
+				param.StatusCode = c.Writer().Status()
+				param.ErrorMessage = c.Errors().ByType(hctx.ErrorTypePrivate).String()
+				param.BodySize = c.Writer().Size()
+
+				if raw != "" {
+					path = path + "?" + raw
+				}
+
+				param.Path = path
+
+				fmt.Fprint(out, formatter(param))
+			})
 		}
 	}
 }
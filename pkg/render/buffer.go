@@ -0,0 +1,48 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which buffers are not returned
+// to the pool, so a single large response does not pin its memory.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// writeBody sets the Content-Length of the response, unless one is set
+// already, and writes the body with a single write.
+func writeBody(w http.ResponseWriter, body []byte) error {
+	header := w.Header()
+	if _, ok := header["Content-Length"]; !ok {
+		header["Content-Length"] = []string{strconv.Itoa(len(body))}
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// trimNewline removes the newline encoders write after every value
+func trimNewline(buf *bytes.Buffer) {
+	if b := buf.Bytes(); len(b) > 0 && b[len(b)-1] == '\n' {
+		buf.Truncate(len(b) - 1)
+	}
+}
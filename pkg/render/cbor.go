@@ -0,0 +1,51 @@
+//go:build !nocbor
+
+package render
+
+import (
+	"net/http"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Check interface implemented here to support go build tag nocbor.
+var (
+	_ Render = CBOR{}
+)
+
+// CBOR contains the given interface object.
+type CBOR struct {
+	Data any
+}
+
+var cborContentType = []string{"application/cbor"}
+
+// cborHandle is shared by the encoders. It is initialized by its first encoder,
+// which happens at package init, so the handle is not changed once shared.
+var cborHandle codec.CborHandle
+
+func init() {
+	var b []byte
+	codec.NewEncoderBytes(&b, &cborHandle)
+}
+
+// WriteContentType (CBOR) writes CBOR ContentType.
+func (r CBOR) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, cborContentType)
+}
+
+// Render (CBOR) encodes the given interface object and writes data with custom ContentType.
+func (r CBOR) Render(w http.ResponseWriter) error {
+	return WriteCBOR(w, r.Data)
+}
+
+// WriteCBOR writes CBOR ContentType and encodes the given interface object.
+func WriteCBOR(w http.ResponseWriter, obj any) error {
+	writeContentType(w, cborContentType)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := codec.NewEncoder(buf, &cborHandle).Encode(obj); err != nil {
+		return err
+	}
+	return writeBody(w, buf.Bytes())
+}
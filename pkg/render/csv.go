@@ -0,0 +1,307 @@
+package render
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV writes Data, a slice or array of structs or pointers to structs, or the
+// rows of Rows as comma separated values.
+//
+// The columns of Data are the exported fields of the struct, named after the
+// `csv` tag or the field name, fields tagged `csv:"-"` are skipped.
+// Header names the columns of Rows, no header row is written when empty.
+// A Header must have as many columns as the data, otherwise Render fails.
+//
+// The rows are streamed, the response is flushed every FlushInterval,
+// DefaultFlushInterval when zero and after every row when negative, and
+// when all rows are written. The interval is checked after every row.
+type CSV struct {
+	Data          any
+	Rows          Rows
+	Header        []string
+	FlushInterval time.Duration
+	// Comma is the field delimiter, ',' when 0.
+	Comma rune
+	// UseCRLF ends the lines with \r\n instead of \n.
+	UseCRLF bool
+	// BOM writes a UTF-8 byte order mark first, so spreadsheets detect the encoding.
+	BOM bool
+	// EscapeFormulas prefixes fields starting with =, +, -, @, tab or carriage
+	// return with a single quote, so spreadsheets do not evaluate them.
+	EscapeFormulas bool
+	// Filename sets the Content-Disposition to download the CSV as an attachment.
+	Filename string
+}
+
+// Rows yields the rows of a CSV.
+type Rows interface {
+	// Next returns the next row and false when there are no more rows.
+	Next() (row []string, ok bool, err error)
+}
+
+// RowsFunc is an adapter to use a function as Rows.
+type RowsFunc func() ([]string, bool, error)
+
+// Next calls f().
+func (f RowsFunc) Next() ([]string, bool, error) {
+	return f()
+}
+
+var csvContentType = []string{"text/csv; charset=utf-8"}
+
+// utf8BOM is the UTF-8 byte order mark
+const utf8BOM = "\xef\xbb\xbf"
+
+// Render (CSV) writes the header and the rows, streaming them to the response.
+func (r CSV) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	if r.Filename != "" {
+		w.Header().Set("Content-Disposition", ContentDisposition("attachment", r.Filename))
+	}
+	if r.BOM {
+		if _, err := w.Write([]byte(utf8BOM)); err != nil {
+			return err
+		}
+	}
+	cw := csv.NewWriter(w)
+	if r.Comma != 0 {
+		cw.Comma = r.Comma
+	}
+	cw.UseCRLF = r.UseCRLF
+	interval := r.FlushInterval
+	if interval == 0 {
+		interval = DefaultFlushInterval
+	}
+	flusher, _ := w.(http.Flusher)
+	flushed := time.Now()
+	flush := func() error {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		flushed = time.Now()
+		return nil
+	}
+	write := func(row []string) error {
+		if r.EscapeFormulas {
+			row = escapeFormulas(row)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		if interval < 0 || time.Since(flushed) >= interval {
+			return flush()
+		}
+		return nil
+	}
+
+	var err error
+	switch {
+	case r.Rows != nil:
+		err = r.writeRows(write)
+	case r.Data != nil:
+		err = r.writeData(write)
+	default:
+		err = errors.New("render: CSV without Data or Rows")
+	}
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// WriteContentType (CSV) writes CSV ContentType.
+func (r CSV) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, csvContentType)
+}
+
+func (r CSV) writeRows(write func([]string) error) error {
+	if len(r.Header) > 0 {
+		if err := write(r.Header); err != nil {
+			return err
+		}
+	}
+	for {
+		row, ok, err := r.Rows.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if len(r.Header) > 0 && len(row) != len(r.Header) {
+			return fmt.Errorf("render: CSV row has %d columns, the header %d", len(row), len(r.Header))
+		}
+		if err := write(row); err != nil {
+			return err
+		}
+	}
+}
+
+func (r CSV) writeData(write func([]string) error) error {
+	v := reflect.ValueOf(r.Data)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Errorf("render: CSV data must be a slice of structs, got %T", r.Data)
+	}
+	t := v.Type().Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("render: CSV data must be a slice of structs, got %T", r.Data)
+	}
+	columns := csvColumns(t)
+	header := r.Header
+	if len(header) > 0 && len(header) != len(columns) {
+		return fmt.Errorf("render: CSV header has %d columns, the data %d", len(header), len(columns))
+	}
+	if len(header) == 0 {
+		header = make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = c.name
+		}
+	}
+	if err := write(header); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Pointer {
+			if elem.IsNil() {
+				break
+			}
+			elem = elem.Elem()
+		}
+		for j, c := range columns {
+			if elem.Kind() != reflect.Struct {
+				row[j] = ""
+				continue
+			}
+			f, err := elem.FieldByIndexErr(c.index)
+			if err != nil {
+				// a nil embedded pointer
+				row[j] = ""
+				continue
+			}
+			row[j] = formatCSVField(f)
+		}
+		if err := write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvColumn is a column of a CSV of structs
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvColumns returns the columns of the struct type
+func csvColumns(t reflect.Type) []csvColumn {
+	fields := reflect.VisibleFields(t)
+	columns := make([]csvColumn, 0, len(fields))
+	for _, f := range fields {
+		if !f.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("csv"), ",")
+		if tag == "-" {
+			continue
+		}
+		// the fields of an embedded struct are columns, not the struct itself
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				continue
+			}
+		}
+		name := tag
+		if name == "" {
+			name = f.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: f.Index})
+	}
+	return columns
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// formatCSVField returns the text of the field value
+func formatCSVField(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		if v.Type().Implements(textMarshalerType) {
+			break
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// escapeFormulas returns the row with the fields spreadsheets evaluate as formulas escaped
+func escapeFormulas(row []string) []string {
+	var escaped []string
+	for i, field := range row {
+		if field == "" || !strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+			continue
+		}
+		if escaped == nil {
+			escaped = append([]string(nil), row...)
+		}
+		escaped[i] = "'" + field
+	}
+	if escaped == nil {
+		return row
+	}
+	return escaped
+}
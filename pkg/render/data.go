@@ -11,8 +11,7 @@ type Data struct {
 // Render (Data) writes data with custom ContentType.
 func (r Data) Render(w http.ResponseWriter) (err error) {
 	r.WriteContentType(w)
-	_, err = w.Write(r.Data)
-	return
+	return writeBody(w, r.Data)
 }
 
 // WriteContentType (Data) writes custom ContentType.
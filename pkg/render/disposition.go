@@ -0,0 +1,73 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentDisposition returns the value of a Content-Disposition header of the
+// disposition type, "attachment" or "inline", with the filename encoded
+// following RFC 6266: an ASCII fallback in the filename parameter and the
+// UTF-8 name in the filename* parameter when the name is not plain ASCII.
+func ContentDisposition(dispositionType, filename string) string {
+	if filename == "" {
+		return dispositionType
+	}
+	fallback, ascii := asciiFilename(filename)
+	var b strings.Builder
+	b.WriteString(dispositionType)
+	b.WriteString(`; filename="`)
+	b.WriteString(fallback)
+	b.WriteByte('"')
+	if !ascii {
+		b.WriteString("; filename*=UTF-8''")
+		b.WriteString(encodeRFC5987(filename))
+	}
+	return b.String()
+}
+
+// asciiFilename returns the filename as a quoted-string value with the
+// non-ASCII and control characters replaced by '_', and true when nothing
+// was replaced
+func asciiFilename(filename string) (string, bool) {
+	ascii := true
+	var b strings.Builder
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f || r >= utf8.RuneSelf:
+			b.WriteByte('_')
+			ascii = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), ascii
+}
+
+// encodeRFC5987 percent-encodes all bytes except the attr-char of RFC 5987
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
+	}
+	return b.String()
+}
+
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// HTMLLayout is an HTMLRender composing every page template with a layout and
+// the partial templates. The layout executes the blocks the page defines, e.g.
+// a layout containing {{block "content" .}}{{end}} renders the "content"
+// template defined by the page.
+//
+// The name given to Instance is the path of the page relative to Pages,
+// optionally prefixed with the name of the layout and a colon, e.g.
+// "admin:users/index". Without a prefix DefaultLayout is used and when that
+// is empty the page is rendered without a layout.
+// The files are parsed as templates named by their path in FS, e.g. a
+// partial is included with {{template "partials/nav.html" .}}.
+// The parsed templates are cached unless Debug is set, so an HTMLLayout
+// must not be copied after first use.
+type HTMLLayout struct {
+	// FS contains the templates.
+	FS fs.FS
+	// Layouts is the directory of the layouts, a layout is named after its file.
+	Layouts string
+	// Pages is the directory of the pages.
+	Pages string
+	// Partials are the glob patterns of the templates available to every page.
+	Partials []string
+	// DefaultLayout is the layout of the pages rendered without a layout prefix.
+	DefaultLayout string
+	// Extension is appended to the layout and page names without one, defaults to ".html".
+	Extension string
+	Delims    Delims
+	FuncMap   template.FuncMap
+	// Debug parses the templates on every render, so changes are visible without restart.
+	Debug bool
+
+	// cache contains the parsed templates by layout and page
+	cache sync.Map
+}
+
+// htmlError is rendered when the templates of an HTML render can not be parsed.
+type htmlError struct {
+	err error
+}
+
+// Instance (HTMLLayout) returns an HTML instance of the page composed with its layout.
+// When the templates can not be parsed the returned Render fails with the error.
+func (r *HTMLLayout) Instance(name string, data any) Render {
+	layout, page, ok := strings.Cut(name, ":")
+	if !ok {
+		layout, page = r.DefaultLayout, name
+	}
+	templ, err := r.template(layout, page)
+	if err != nil {
+		return htmlError{err: err}
+	}
+	return HTML{
+		Template: templ,
+		Name:     templ.Name(),
+		Data:     data,
+	}
+}
+
+// template returns the parsed template of the page composed with the layout
+func (r *HTMLLayout) template(layout, page string) (*template.Template, error) {
+	key := layout + ":" + page
+	if !r.Debug {
+		if templ, ok := r.cache.Load(key); ok {
+			return templ.(*template.Template), nil
+		}
+	}
+	templ, err := r.parse(layout, page)
+	if err != nil {
+		return nil, err
+	}
+	if !r.Debug {
+		r.cache.Store(key, templ)
+	}
+	return templ, nil
+}
+
+func (r *HTMLLayout) parse(layout, page string) (*template.Template, error) {
+	if r.FS == nil {
+		return nil, fmt.Errorf("html layout: no file system to load page %q from", page)
+	}
+	// the template executed is the first one parsed
+	var files []string
+	if layout != "" {
+		files = append(files, path.Join(r.Layouts, r.withExtension(layout)))
+	}
+	for _, pattern := range r.Partials {
+		matches, err := fs.Glob(r.FS, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("html layout: partials %q: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	pageFile := path.Join(r.Pages, r.withExtension(page))
+	files = append(files, pageFile)
+
+	// the templates are named by their path in FS, files sharing a base name
+	// in different directories do not replace each other
+	main := files[0]
+	if layout == "" {
+		main = pageFile
+	}
+	templ := template.New("").Delims(r.Delims.Left, r.Delims.Right).Funcs(r.FuncMap)
+	for _, file := range files {
+		b, err := fs.ReadFile(r.FS, file)
+		if err != nil {
+			return nil, fmt.Errorf("html layout: %w", err)
+		}
+		if _, err := templ.New(file).Parse(string(b)); err != nil {
+			return nil, fmt.Errorf("html layout: %w", err)
+		}
+	}
+	return templ.Lookup(main), nil
+}
+
+func (r *HTMLLayout) withExtension(name string) string {
+	if path.Ext(name) != "" {
+		return name
+	}
+	if r.Extension == "" {
+		return name + ".html"
+	}
+	return name + r.Extension
+}
+
+// Render (htmlError) returns the error of parsing the templates.
+func (r htmlError) Render(http.ResponseWriter) error {
+	return r.err
+}
+
+// WriteContentType (htmlError) writes HTML ContentType.
+func (r htmlError) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, htmlContentType)
+}
@@ -2,12 +2,13 @@ package render
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
 	"html/template"
 	"net/http"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/idproxy/httpserver/internal/bytesconv"
+	"github.com/idproxy/httpserver/pkg/codec"
 )
 
 // JSON contains the given interface object.
@@ -61,23 +62,38 @@ func (r JSON) WriteContentType(w http.ResponseWriter) {
 // WriteJSON marshals the given interface object and writes it with custom ContentType.
 func WriteJSON(w http.ResponseWriter, obj any) error {
 	writeContentType(w, jsonContentType)
-	jsonBytes, err := json.Marshal(obj)
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := encodeJSON(buf, obj, true, ""); err != nil {
 		return err
 	}
-	_, err = w.Write(jsonBytes)
-	return err
+	return writeBody(w, buf.Bytes())
+}
+
+// encodeJSON encodes the object into the buffer without the trailing newline
+// of the encoder, which matches the output of Marshal and MarshalIndent.
+func encodeJSON(buf *bytes.Buffer, obj any, escapeHTML bool, indent string) error {
+	enc := codec.GetJSON().NewEncoder(buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	if err := enc.Encode(obj); err != nil {
+		return err
+	}
+	trimNewline(buf)
+	return nil
 }
 
 // Render (IndentedJSON) marshals the given interface object and writes it with custom ContentType.
 func (r IndentedJSON) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
-	jsonBytes, err := json.MarshalIndent(r.Data, "", "    ")
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := encodeJSON(buf, r.Data, true, "    "); err != nil {
 		return err
 	}
-	_, err = w.Write(jsonBytes)
-	return err
+	return writeBody(w, buf.Bytes())
 }
 
 // WriteContentType (IndentedJSON) writes JSON ContentType.
@@ -88,19 +104,21 @@ func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
 // Render (SecureJSON) marshals the given interface object and writes it with custom ContentType.
 func (r SecureJSON) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
-	jsonBytes, err := json.Marshal(r.Data)
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	// the prefix is encoded in front of the json and skipped when not needed
+	buf.WriteString(r.Prefix)
+	if err := encodeJSON(buf, r.Data, true, ""); err != nil {
 		return err
 	}
+	body := buf.Bytes()
+	jsonBytes := body[len(r.Prefix):]
 	// if the jsonBytes is array values
 	if bytes.HasPrefix(jsonBytes, bytesconv.StringToBytes("[")) && bytes.HasSuffix(jsonBytes,
 		bytesconv.StringToBytes("]")) {
-		if _, err = w.Write(bytesconv.StringToBytes(r.Prefix)); err != nil {
-			return err
-		}
+		return writeBody(w, body)
 	}
-	_, err = w.Write(jsonBytes)
-	return err
+	return writeBody(w, jsonBytes)
 }
 
 // WriteContentType (SecureJSON) writes JSON ContentType.
@@ -111,34 +129,23 @@ func (r SecureJSON) WriteContentType(w http.ResponseWriter) {
 // Render (JsonpJSON) marshals the given interface object and writes it and its callback with custom ContentType.
 func (r JsonpJSON) Render(w http.ResponseWriter) (err error) {
 	r.WriteContentType(w)
-	ret, err := json.Marshal(r.Data)
-	if err != nil {
-		return err
-	}
+	buf := getBuffer()
+	defer putBuffer(buf)
 
 	if r.Callback == "" {
-		_, err = w.Write(ret)
-		return err
-	}
-
-	callback := template.JSEscapeString(r.Callback)
-	if _, err = w.Write(bytesconv.StringToBytes(callback)); err != nil {
-		return err
-	}
-
-	if _, err = w.Write(bytesconv.StringToBytes("(")); err != nil {
-		return err
-	}
-
-	if _, err = w.Write(ret); err != nil {
-		return err
+		if err := encodeJSON(buf, r.Data, true, ""); err != nil {
+			return err
+		}
+		return writeBody(w, buf.Bytes())
 	}
 
-	if _, err = w.Write(bytesconv.StringToBytes(");")); err != nil {
+	buf.WriteString(template.JSEscapeString(r.Callback))
+	buf.WriteByte('(')
+	if err := encodeJSON(buf, r.Data, true, ""); err != nil {
 		return err
 	}
-
-	return nil
+	buf.WriteString(");")
+	return writeBody(w, buf.Bytes())
 }
 
 // WriteContentType (JsonpJSON) writes Javascript ContentType.
@@ -149,22 +156,45 @@ func (r JsonpJSON) WriteContentType(w http.ResponseWriter) {
 // Render (AsciiJSON) marshals the given interface object and writes it with custom ContentType.
 func (r AsciiJSON) Render(w http.ResponseWriter) (err error) {
 	r.WriteContentType(w)
-	ret, err := json.Marshal(r.Data)
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := encodeJSON(buf, r.Data, true, ""); err != nil {
 		return err
 	}
 
-	var buffer bytes.Buffer
-	for _, r := range bytesconv.BytesToString(ret) {
-		cvt := string(r)
-		if r >= 128 {
-			cvt = fmt.Sprintf("\\u%04x", int64(r))
+	ascii := getBuffer()
+	defer putBuffer(ascii)
+	writeASCII(ascii, buf.Bytes())
+	return writeBody(w, ascii.Bytes())
+}
+
+// writeASCII writes the json to the buffer with all non-ASCII characters escaped
+// as \uXXXX, characters outside the basic multilingual plane as a surrogate pair.
+func writeASCII(buf *bytes.Buffer, json []byte) {
+	const hex = "0123456789abcdef"
+	buf.Grow(len(json))
+	start := 0
+	for i := 0; i < len(json); {
+		if json[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		buf.Write(json[start:i])
+		r, size := utf8.DecodeRune(json[i:])
+		r1, r2 := utf16.EncodeRune(r)
+		if r1 == utf8.RuneError {
+			r1, r2 = r, -1
+		}
+		for _, u := range [2]rune{r1, r2} {
+			if u < 0 {
+				continue
+			}
+			buf.Write([]byte{'\\', 'u', hex[u>>12&0xf], hex[u>>8&0xf], hex[u>>4&0xf], hex[u&0xf]})
 		}
-		buffer.WriteString(cvt)
+		i += size
+		start = i
 	}
-
-	_, err = w.Write(buffer.Bytes())
-	return err
+	buf.Write(json[start:])
 }
 
 // WriteContentType (AsciiJSON) writes JSON ContentType.
@@ -175,9 +205,14 @@ func (r AsciiJSON) WriteContentType(w http.ResponseWriter) {
 // Render (PureJSON) writes custom ContentType and encodes the given interface object.
 func (r PureJSON) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
-	encoder := json.NewEncoder(w)
-	encoder.SetEscapeHTML(false)
-	return encoder.Encode(r.Data)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	enc := codec.GetJSON().NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(r.Data); err != nil {
+		return err
+	}
+	return writeBody(w, buf.Bytes())
 }
 
 // WriteContentType (PureJSON) writes custom ContentType.
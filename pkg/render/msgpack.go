@@ -21,6 +21,15 @@ type MsgPack struct {
 
 var msgpackContentType = []string{"application/msgpack; charset=utf-8"}
 
+// msgpackHandle is shared by the encoders. It is initialized by its first encoder,
+// which happens at package init, so the handle is not changed once shared.
+var msgpackHandle codec.MsgpackHandle
+
+func init() {
+	var b []byte
+	codec.NewEncoderBytes(&b, &msgpackHandle)
+}
+
 // WriteContentType (MsgPack) writes MsgPack ContentType.
 func (r MsgPack) WriteContentType(w http.ResponseWriter) {
 	writeContentType(w, msgpackContentType)
@@ -34,6 +43,10 @@ func (r MsgPack) Render(w http.ResponseWriter) error {
 // WriteMsgPack writes MsgPack ContentType and encodes the given interface object.
 func WriteMsgPack(w http.ResponseWriter, obj any) error {
 	writeContentType(w, msgpackContentType)
-	var mh codec.MsgpackHandle
-	return codec.NewEncoder(w, &mh).Encode(obj)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := codec.NewEncoder(buf, &msgpackHandle).Encode(obj); err != nil {
+		return err
+	}
+	return writeBody(w, buf.Bytes())
 }
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ProblemContentType is the media type of a problem details JSON document.
+const ProblemContentType = "application/problem+json"
+
+// Problem contains the problem details of an error response, RFC 9457.
+// The Extensions are additional members of the problem details. An extension
+// named like a standard member (type, title, status, detail or instance)
+// fails the rendering, also when the standard member is empty.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+var problemContentType = []string{ProblemContentType}
+
+// NewProblem returns the problem details of the status with the detail,
+// the type is "about:blank" and the title the status text.
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// MarshalJSON returns the problem details document, empty members are omitted.
+func (r Problem) MarshalJSON() ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	m, err := r.members()
+	if err != nil {
+		return nil, err
+	}
+	if err := encodeJSON(buf, m, true, ""); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// problemMembers are the names of the standard members of problem details
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+func (r Problem) members() (map[string]any, error) {
+	m := make(map[string]any, len(r.Extensions)+5)
+	for _, k := range problemMembers {
+		if _, ok := r.Extensions[k]; ok {
+			return nil, fmt.Errorf("problem: extension %q collides with a standard member", k)
+		}
+	}
+	for k, v := range r.Extensions {
+		m[k] = v
+	}
+	set := func(k string, v any, empty bool) {
+		if !empty {
+			m[k] = v
+		}
+	}
+	set("type", r.Type, r.Type == "")
+	set("title", r.Title, r.Title == "")
+	set("status", r.Status, r.Status == 0)
+	set("detail", r.Detail, r.Detail == "")
+	set("instance", r.Instance, r.Instance == "")
+	return m, nil
+}
+
+// Render (Problem) writes the problem details with the problem ContentType.
+func (r Problem) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	m, err := r.members()
+	if err != nil {
+		return err
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := encodeJSON(buf, m, true, ""); err != nil {
+		return err
+	}
+	return writeBody(w, buf.Bytes())
+}
+
+// WriteContentType (Problem) writes the problem ContentType.
+func (r Problem) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, problemContentType)
+}
@@ -16,14 +16,12 @@ var protobufContentType = []string{"application/x-protobuf"}
 // Render (ProtoBuf) marshals the given interface object and writes data with custom ContentType.
 func (r ProtoBuf) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
-
-	bytes, err := proto.Marshal(r.Data.(proto.Message))
+	// proto.Marshal sizes the slice up front, a pooled buffer saves nothing
+	b, err := proto.Marshal(r.Data.(proto.Message))
 	if err != nil {
 		return err
 	}
-
-	_, err = w.Write(bytes)
-	return err
+	return writeBody(w, b)
 }
 
 // WriteContentType (ProtoBuf) writes ProtoBuf ContentType.
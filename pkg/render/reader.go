@@ -4,26 +4,45 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // Reader contains the IO reader and its length, and custom ContentType and other headers.
+//
+// When Request is set and Reader is an io.ReadSeeker, the content is served
+// with http.ServeContent, answering byte-range requests and the conditional
+// requests matching ModTime or the ETag header.
 type Reader struct {
 	ContentType   string
 	ContentLength int64
 	Reader        io.Reader
 	Headers       map[string]string
+	Request       *http.Request
+	ModTime       time.Time
 }
 
 // Render (Reader) writes data with custom ContentType and headers.
 func (r Reader) Render(w http.ResponseWriter) (err error) {
+	if rs, ok := r.Reader.(io.ReadSeeker); ok && r.Request != nil {
+		// without content type, ServeContent sniffs it from the content
+		if r.ContentType != "" {
+			r.WriteContentType(w)
+		}
+		r.writeHeaders(w, r.Headers)
+		http.ServeContent(w, r.Request, "", r.ModTime, rs)
+		return nil
+	}
 	r.WriteContentType(w)
+	headers := r.Headers
 	if r.ContentLength >= 0 {
-		if r.Headers == nil {
-			r.Headers = map[string]string{}
+		// the headers are owned by the caller, they are not modified
+		headers = make(map[string]string, len(r.Headers)+1)
+		for k, v := range r.Headers {
+			headers[k] = v
 		}
-		r.Headers["Content-Length"] = strconv.FormatInt(r.ContentLength, 10)
+		headers["Content-Length"] = strconv.FormatInt(r.ContentLength, 10)
 	}
-	r.writeHeaders(w, r.Headers)
+	r.writeHeaders(w, headers)
 	_, err = io.Copy(w, r.Reader)
 	return
 }
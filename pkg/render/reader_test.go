@@ -17,3 +17,19 @@ func TestReaderRenderNoHeaders(t *testing.T) {
 	err := r.Render(httptest.NewRecorder())
 	require.NoError(t, err)
 }
+
+func TestReaderRenderHeadersNotModified(t *testing.T) {
+	content := "test"
+	headers := map[string]string{"X-Extra": "1"}
+	r := Reader{
+		ContentType:   "text/plain",
+		ContentLength: int64(len(content)),
+		Reader:        strings.NewReader(content),
+		Headers:       headers,
+	}
+	w := httptest.NewRecorder()
+	require.NoError(t, r.Render(w))
+	require.Equal(t, "4", w.Header().Get("Content-Length"))
+	require.Equal(t, "1", w.Header().Get("X-Extra"))
+	require.Equal(t, map[string]string{"X-Extra": "1"}, headers)
+}
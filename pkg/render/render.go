@@ -22,11 +22,16 @@ var (
 	_ Render     = HTML{}
 	_ HTMLRender = HTMLDebug{}
 	_ HTMLRender = HTMLProduction{}
+	_ HTMLRender = (*HTMLLayout)(nil)
 	_ Render     = YAML{}
 	_ Render     = Reader{}
 	_ Render     = AsciiJSON{}
 	_ Render     = ProtoBuf{}
 	_ Render     = TOML{}
+	_ Render     = NDJSON{}
+	_ Render     = JSONArray{}
+	_ Render     = SSEvent{}
+	_ Render     = Problem{}
 )
 
 func writeContentType(w http.ResponseWriter, value []string) {
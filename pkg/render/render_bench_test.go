@@ -0,0 +1,92 @@
+package render
+
+import (
+	"net/http"
+	"testing"
+
+	testdata "github.com/gin-gonic/gin/testdata/protoexample"
+	"google.golang.org/protobuf/proto"
+)
+
+// discardWriter is a http.ResponseWriter discarding the body, reusing its header
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardWriter) WriteHeader(int) {}
+
+type benchUser struct {
+	ID    int      `json:"id" xml:"id" yaml:"id" toml:"id"`
+	Name  string   `json:"name" xml:"name" yaml:"name" toml:"name"`
+	Email string   `json:"email" xml:"email" yaml:"email" toml:"email"`
+	Tags  []string `json:"tags" xml:"tags" yaml:"tags" toml:"tags"`
+}
+
+var benchData = benchUser{ID: 42, Name: "Gopher 语言", Email: "gopher@example.com", Tags: []string{"a", "b", "c"}}
+
+func benchmarkRender(b *testing.B, r Render) {
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for k := range w.header {
+			delete(w.header, k)
+		}
+		if err := r.Render(w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSON(b *testing.B) {
+	benchmarkRender(b, JSON{benchData})
+}
+
+func BenchmarkIndentedJSON(b *testing.B) {
+	benchmarkRender(b, IndentedJSON{benchData})
+}
+
+func BenchmarkSecureJSON(b *testing.B) {
+	benchmarkRender(b, SecureJSON{"while(1);", []benchUser{benchData}})
+}
+
+func BenchmarkJsonpJSON(b *testing.B) {
+	benchmarkRender(b, JsonpJSON{"callback", benchData})
+}
+
+func BenchmarkAsciiJSON(b *testing.B) {
+	benchmarkRender(b, AsciiJSON{benchData})
+}
+
+func BenchmarkPureJSON(b *testing.B) {
+	benchmarkRender(b, PureJSON{benchData})
+}
+
+func BenchmarkXML(b *testing.B) {
+	benchmarkRender(b, XML{benchData})
+}
+
+func BenchmarkYAML(b *testing.B) {
+	benchmarkRender(b, YAML{benchData})
+}
+
+func BenchmarkTOML(b *testing.B) {
+	benchmarkRender(b, TOML{benchData})
+}
+
+func BenchmarkProtoBuf(b *testing.B) {
+	label := "test"
+	benchmarkRender(b, ProtoBuf{&testdata.Test{Label: &label, Reps: []int64{1, 2, 3}, Type: proto.Int32(1)}})
+}
+
+func BenchmarkData(b *testing.B) {
+	benchmarkRender(b, Data{ContentType: "text/plain", Data: []byte("hello world")})
+}
@@ -0,0 +1,30 @@
+//go:build !nocbor
+
+package render
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestRenderCBOR(t *testing.T) {
+	w := httptest.NewRecorder()
+	data := map[string]any{
+		"foo": "bar",
+	}
+
+	err := (CBOR{data}).Render(w)
+	assert.NoError(t, err)
+
+	buf := bytes.NewBuffer([]byte{})
+	err = codec.NewEncoder(buf, new(codec.CborHandle)).Encode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, buf.String(), w.Body.String())
+	assert.Equal(t, "application/cbor", w.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.Itoa(buf.Len()), w.Header().Get("Content-Length"))
+}
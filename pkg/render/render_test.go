@@ -1,19 +1,27 @@
 package render
 
 import (
+	"context"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"html/template"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	testdata "github.com/gin-gonic/gin/testdata/protoexample"
+	"github.com/idproxy/httpserver/pkg/codec"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // TODO unit tests
@@ -528,3 +536,350 @@ func TestRenderReaderNoContentLength(t *testing.T) {
 	assert.Equal(t, headers["Content-Disposition"], w.Header().Get("Content-Disposition"))
 	assert.Equal(t, headers["x-request-id"], w.Header().Get("x-request-id"))
 }
+
+func TestRenderHTMLDebugFS(t *testing.T) {
+	w := httptest.NewRecorder()
+	htmlRender := HTMLDebug{
+		FS:       os.DirFS("../testdata"),
+		Patterns: []string{"template/hello*"},
+		Delims:   Delims{Left: "{[{", Right: "}]}"},
+	}
+	instance := htmlRender.Instance("hello.tmpl", map[string]any{
+		"name": "thinkerou",
+	})
+
+	err := instance.Render(w)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "<h1>Hello thinkerou</h1>", w.Body.String())
+}
+
+func newHTMLLayoutFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html":     {Data: []byte(`<html>{{template "nav" .}}{{block "content" .}}empty{{end}}</html>`)},
+		"layouts/admin.html":    {Data: []byte(`<admin>{{block "content" .}}{{end}}</admin>`)},
+		"partials/nav.html":     {Data: []byte(`{{define "nav"}}<nav>{{.}}</nav>{{end}}`)},
+		"pages/index.html":      {Data: []byte(`{{define "content"}}<p>{{.}}</p>{{end}}`)},
+		"pages/users/list.html": {Data: []byte(`{{define "content"}}<ul>{{.}}</ul>{{end}}`)},
+		"pages/plain.html":      {Data: []byte(`plain {{.}}`)},
+		"pages/broken.html":     {Data: []byte(`{{define "content"}}{{.}`)},
+	}
+}
+
+func TestRenderHTMLLayout(t *testing.T) {
+	htmlRender := &HTMLLayout{
+		FS:            newHTMLLayoutFS(),
+		Layouts:       "layouts",
+		Pages:         "pages",
+		Partials:      []string{"partials/*.html"},
+		DefaultLayout: "base",
+	}
+	tests := map[string]string{
+		"index":       "<html><nav>alice</nav><p>alice</p></html>",
+		"users/list":  "<html><nav>alice</nav><ul>alice</ul></html>",
+		"admin:index": "<admin><p>alice</p></admin>",
+		":plain.html": "plain alice",
+	}
+	for name, want := range tests {
+		w := httptest.NewRecorder()
+		err := htmlRender.Instance(name, "alice").Render(w)
+		assert.NoError(t, err, name)
+		assert.Equal(t, want, w.Body.String(), name)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	}
+
+	w := httptest.NewRecorder()
+	assert.Error(t, htmlRender.Instance("broken", nil).Render(w))
+	assert.Error(t, htmlRender.Instance("missing", nil).Render(w))
+}
+
+func TestRenderHTMLLayoutSameBaseName(t *testing.T) {
+	htmlRender := &HTMLLayout{
+		FS: fstest.MapFS{
+			"layouts/index.html":     {Data: []byte(`<html>{{template "partials/form.html" .}}{{block "content" .}}{{end}}</html>`)},
+			"partials/form.html":     {Data: []byte(`<form>{{.}}</form>`)},
+			"pages/index.html":       {Data: []byte(`{{define "content"}}<p>{{.}}</p>{{end}}`)},
+			"pages/admin/form.html":  {Data: []byte(`{{define "content"}}<admin>{{.}}</admin>{{end}}`)},
+			"pages/users/index.html": {Data: []byte(`{{define "content"}}<ul>{{.}}</ul>{{end}}`)},
+		},
+		Layouts:       "layouts",
+		Pages:         "pages",
+		Partials:      []string{"partials/*.html"},
+		DefaultLayout: "index",
+	}
+	tests := map[string]string{
+		"index":       "<html><form>alice</form><p>alice</p></html>",
+		"users/index": "<html><form>alice</form><ul>alice</ul></html>",
+		"admin/form":  "<html><form>alice</form><admin>alice</admin></html>",
+	}
+	for name, want := range tests {
+		w := httptest.NewRecorder()
+		assert.NoError(t, htmlRender.Instance(name, "alice").Render(w), name)
+		assert.Equal(t, want, w.Body.String(), name)
+	}
+}
+
+func TestRenderHTMLLayoutCache(t *testing.T) {
+	fsys := newHTMLLayoutFS()
+	htmlRender := &HTMLLayout{FS: fsys, Layouts: "layouts", Pages: "pages", DefaultLayout: "admin"}
+
+	w := httptest.NewRecorder()
+	assert.NoError(t, htmlRender.Instance("index", 1).Render(w))
+	fsys["pages/index.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}v2{{end}}`)}
+	w = httptest.NewRecorder()
+	assert.NoError(t, htmlRender.Instance("index", 1).Render(w))
+	assert.Equal(t, "<admin><p>1</p></admin>", w.Body.String())
+
+	htmlRender = &HTMLLayout{FS: fsys, Layouts: "layouts", Pages: "pages", DefaultLayout: "admin", Debug: true}
+	w = httptest.NewRecorder()
+	assert.NoError(t, htmlRender.Instance("index", 1).Render(w))
+	assert.Equal(t, "<admin>v2</admin>", w.Body.String())
+}
+
+func TestRenderJSONCodec(t *testing.T) {
+	codec.SetJSON(codec.ProtoJSON{})
+	defer codec.SetJSON(nil)
+
+	w := httptest.NewRecorder()
+	err := (JSON{durationpb.New(time.Second)}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, `"1s"`, w.Body.String())
+}
+
+func TestRenderContentLength(t *testing.T) {
+	renders := []Render{
+		JSON{map[string]int{"a": 1}},
+		IndentedJSON{map[string]int{"a": 1}},
+		SecureJSON{"while(1);", []int{1}},
+		JsonpJSON{"cb", 1},
+		AsciiJSON{"语言"},
+		PureJSON{"<b>"},
+		XML{xmlmap{"a": "b"}},
+		YAML{map[string]int{"a": 1}},
+		TOML{map[string]int{"a": 1}},
+		Data{"text/plain", []byte("abc")},
+	}
+	for _, r := range renders {
+		w := httptest.NewRecorder()
+		assert.NoError(t, r.Render(w))
+		assert.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"), "%T", r)
+	}
+}
+
+func TestRenderAsciiJSONSurrogatePair(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.NoError(t, (AsciiJSON{"a😀"}).Render(w))
+	assert.Equal(t, `"a\ud83d\ude00"`, w.Body.String())
+}
+
+func TestRenderNDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := (NDJSON{Source: SliceSource([]map[string]int{{"a": 1}, {"a": 2}})}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"a\":1}\n{\"a\":2}\n", w.Body.String())
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.True(t, w.Flushed)
+}
+
+func TestRenderJSONArray(t *testing.T) {
+	ch := make(chan int)
+	go func() {
+		for i := 1; i <= 3; i++ {
+			ch <- i
+		}
+		close(ch)
+	}()
+	w := httptest.NewRecorder()
+	err := (JSONArray{Source: ChanSource(ch), FlushInterval: -1}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,2,3]", w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	err = (JSONArray{Source: SliceSource([]int{})}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestRenderNDJSONReusedValue(t *testing.T) {
+	// the source scans every row into the same value
+	row := &struct {
+		ID int `json:"id"`
+	}{}
+	src := SourceFunc(func(context.Context) (any, bool, error) {
+		if row.ID == 100 {
+			return nil, false, nil
+		}
+		row.ID++
+		return row, true, nil
+	})
+	w := httptest.NewRecorder()
+	assert.NoError(t, (NDJSON{Source: src}).Render(w))
+
+	var want strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&want, "{\"id\":%d}\n", i)
+	}
+	assert.Equal(t, want.String(), w.Body.String())
+}
+
+func TestRenderStreamErrors(t *testing.T) {
+	failing := errors.New("source failed")
+	w := httptest.NewRecorder()
+	err := (NDJSON{Source: SourceFunc(func(context.Context) (any, bool, error) {
+		return nil, false, failing
+	})}).Render(w)
+	assert.ErrorIs(t, err, failing)
+
+	// a cancelled context stops the stream without error
+	ctx, cancel := context.WithCancel(context.Background())
+	sent := false
+	src := SourceFunc(func(ctx context.Context) (any, bool, error) {
+		if !sent {
+			sent = true
+			return 1, true, nil
+		}
+		cancel()
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	})
+	w = httptest.NewRecorder()
+	err = (NDJSON{Context: ctx, Source: src}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n", w.Body.String())
+}
+
+func TestRenderSSEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := (SSEvent{Event: "message", ID: "1\n2", Retry: 3000, Data: "line1\nline2"}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 12\nevent: message\nretry: 3000\ndata: line1\ndata: line2\n\n", w.Body.String())
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+	w = httptest.NewRecorder()
+	err = (SSEvent{Data: map[string]int{"a": 1}}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "data: {\"a\":1}\n\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.NoError(t, SSEComment(w, "keep-alive"))
+	assert.Equal(t, ": keep-alive\n\n", w.Body.String())
+}
+
+func TestRenderProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	problem := NewProblem(http.StatusConflict, "the user exists")
+	problem.Instance = "/users/alice"
+	problem.Extensions = map[string]any{"user": "alice"}
+	err := problem.Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Conflict",
+		"status": 409,
+		"detail": "the user exists",
+		"instance": "/users/alice",
+		"user": "alice"
+	}`, w.Body.String())
+
+	b, err := json.Marshal(Problem{Title: "only a title"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"title":"only a title"}`, string(b))
+
+	// an extension colliding with a standard member is not dropped silently
+	problem = Problem{Title: "collision", Extensions: map[string]any{"detail": "lost"}}
+	assert.EqualError(t, problem.Render(httptest.NewRecorder()), `problem: extension "detail" collides with a standard member`)
+	_, err = json.Marshal(problem)
+	assert.Error(t, err)
+}
+
+func TestRenderCSV(t *testing.T) {
+	type base struct {
+		ID int `csv:"id"`
+	}
+	type user struct {
+		base
+		Name    string    `csv:"name"`
+		Score   float64   `csv:"score"`
+		Active  bool      `csv:"active"`
+		Joined  time.Time `csv:"joined"`
+		Email   *string
+		secret  string
+		Ignored string `csv:"-"`
+	}
+	joined := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := []*user{
+		{base: base{ID: 1}, Name: `Doe, "Jo"`, Score: 1.5, Active: true, Joined: joined, secret: "x", Ignored: "x"},
+		nil,
+		{base: base{ID: 2}, Name: "=SUM(A1)"},
+	}
+
+	w := httptest.NewRecorder()
+	err := (CSV{Data: data, BOM: true, EscapeFormulas: true, Filename: "users.csv"}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="users.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "\xef\xbb\xbf"+
+		"id,name,score,active,joined,Email\n"+
+		"1,\"Doe, \"\"Jo\"\"\",1.5,true,2024-01-02T03:04:05Z,\n"+
+		",,,,,\n"+
+		"2,'=SUM(A1),0,false,,\n", w.Body.String())
+
+	rows := [][]string{{"a", "b"}, {"c", "d"}}
+	w = httptest.NewRecorder()
+	err = (CSV{Header: []string{"x", "y"}, Comma: ';', UseCRLF: true, Rows: RowsFunc(func() ([]string, bool, error) {
+		if len(rows) == 0 {
+			return nil, false, nil
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, true, nil
+	})}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "x;y\r\na;b\r\nc;d\r\n", w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+
+	failing := errors.New("rows failed")
+	err = (CSV{Rows: RowsFunc(func() ([]string, bool, error) { return nil, false, failing })}).Render(httptest.NewRecorder())
+	assert.ErrorIs(t, err, failing)
+
+	assert.Error(t, (CSV{Data: []int{1}}).Render(httptest.NewRecorder()))
+	assert.Error(t, (CSV{}).Render(httptest.NewRecorder()))
+
+	err = (CSV{Data: data, Header: []string{"id", "name"}}).Render(httptest.NewRecorder())
+	assert.EqualError(t, err, "render: CSV header has 2 columns, the data 6")
+	err = (CSV{Header: []string{"x", "y"}, Rows: RowsFunc(func() ([]string, bool, error) {
+		return []string{"a"}, true, nil
+	})}).Render(httptest.NewRecorder())
+	assert.EqualError(t, err, "render: CSV row has 1 columns, the header 2")
+}
+
+func TestRenderCSVFlush(t *testing.T) {
+	// every row reaches the client before the next one is produced
+	var w *httptest.ResponseRecorder
+	n := 0
+	rows := RowsFunc(func() ([]string, bool, error) {
+		if n > 0 {
+			assert.Equal(t, strings.Repeat("row\n", n), w.Body.String())
+			assert.True(t, w.Flushed)
+		}
+		if n == 3 {
+			return nil, false, nil
+		}
+		n++
+		return []string{"row"}, true, nil
+	})
+	w = httptest.NewRecorder()
+	assert.NoError(t, (CSV{Rows: rows, FlushInterval: -1}).Render(w))
+	assert.Equal(t, "row\nrow\nrow\n", w.Body.String())
+}
+
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, "attachment", ContentDisposition("attachment", ""))
+	assert.Equal(t, `inline; filename="report.pdf"`, ContentDisposition("inline", "report.pdf"))
+	assert.Equal(t, `attachment; filename="a \"b\".txt"`, ContentDisposition("attachment", `a "b".txt`))
+	assert.Equal(t, `attachment; filename="r_sum_.csv"; filename*=UTF-8''r%C3%A9sum%C3%A9.csv`,
+		ContentDisposition("attachment", "résumé.csv"))
+}
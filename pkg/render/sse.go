@@ -0,0 +1,97 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SSEvent is a server-sent event, see
+// https://html.spec.whatwg.org/multipage/server-sent-events.html
+// Data is written as is when it is a string or []byte, every line as a data
+// field, other values are encoded as JSON.
+type SSEvent struct {
+	Event string
+	ID    string
+	// Retry is the reconnection time in milliseconds, omitted when 0.
+	Retry uint
+	Data  any
+}
+
+var sseContentType = []string{"text/event-stream"}
+
+// fieldReplacer removes the line breaks from the single line event and id fields
+var fieldReplacer = strings.NewReplacer("\n", "", "\r", "")
+
+// Render (SSEvent) writes the event.
+func (r SSEvent) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := r.encode(buf); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteContentType (SSEvent) writes the event stream ContentType and disables caching.
+func (r SSEvent) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, sseContentType)
+	header := w.Header()
+	if _, ok := header["Cache-Control"]; !ok {
+		header["Cache-Control"] = []string{"no-cache"}
+	}
+}
+
+func (r SSEvent) encode(buf *bytes.Buffer) error {
+	if r.ID != "" {
+		buf.WriteString("id: ")
+		// the id field may not contain null characters
+		buf.WriteString(strings.ReplaceAll(fieldReplacer.Replace(r.ID), "\x00", ""))
+		buf.WriteByte('\n')
+	}
+	if r.Event != "" {
+		buf.WriteString("event: ")
+		buf.WriteString(fieldReplacer.Replace(r.Event))
+		buf.WriteByte('\n')
+	}
+	if r.Retry > 0 {
+		buf.WriteString("retry: ")
+		buf.WriteString(strconv.FormatUint(uint64(r.Retry), 10))
+		buf.WriteByte('\n')
+	}
+	var data string
+	switch d := r.Data.(type) {
+	case nil:
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		b := getBuffer()
+		defer putBuffer(b)
+		if err := encodeJSON(b, d, true, ""); err != nil {
+			return err
+		}
+		data = b.String()
+	}
+	if r.Data != nil {
+		data = strings.ReplaceAll(strings.ReplaceAll(data, "\r\n", "\n"), "\r", "\n")
+		for _, line := range strings.Split(data, "\n") {
+			buf.WriteString("data: ")
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	// an empty line dispatches the event
+	buf.WriteByte('\n')
+	return nil
+}
+
+// SSEComment writes a comment line, ignored by clients, e.g. to keep the connection alive.
+func SSEComment(w http.ResponseWriter, comment string) error {
+	_, err := w.Write([]byte(": " + fieldReplacer.Replace(comment) + "\n\n"))
+	return err
+}
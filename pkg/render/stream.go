@@ -0,0 +1,232 @@
+package render
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/idproxy/httpserver/pkg/codec"
+)
+
+// DefaultFlushInterval is the flush interval of the streaming renderers
+// created without one.
+const DefaultFlushInterval = time.Second
+
+// Source yields the values of a stream.
+type Source interface {
+	// Next returns the next value of the stream and false when the stream ended.
+	// Next is called again only after the previous value is written, so the
+	// value may be reused, e.g. scanned into the same struct on every call.
+	// Next must return when the context is done, otherwise the goroutine
+	// calling it leaks once the client went away.
+	Next(ctx context.Context) (value any, ok bool, err error)
+}
+
+// SourceFunc is an adapter to use a function as a Source.
+type SourceFunc func(ctx context.Context) (any, bool, error)
+
+// Next calls f(ctx).
+func (f SourceFunc) Next(ctx context.Context) (any, bool, error) {
+	return f(ctx)
+}
+
+// SliceSource returns a Source yielding the values of the slice.
+func SliceSource[T any](values []T) Source {
+	i := 0
+	return SourceFunc(func(context.Context) (any, bool, error) {
+		if i >= len(values) {
+			return nil, false, nil
+		}
+		i++
+		return values[i-1], true, nil
+	})
+}
+
+// ChanSource returns a Source yielding the values received from the channel
+// until it is closed.
+func ChanSource[T any](ch <-chan T) Source {
+	return SourceFunc(func(ctx context.Context) (any, bool, error) {
+		select {
+		case v, ok := <-ch:
+			return v, ok, nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	})
+}
+
+// NDJSON streams the values of the source as newline delimited JSON.
+// The response is flushed every FlushInterval, DefaultFlushInterval when
+// zero and after every value when negative, and when the stream ends.
+// Streaming stops without error when the Context, typically the context
+// of the request, is done because the client went away.
+type NDJSON struct {
+	Context       context.Context
+	Source        Source
+	FlushInterval time.Duration
+}
+
+// JSONArray streams the values of the source as the elements of a JSON array.
+// Flushing and stopping behaves like NDJSON.
+type JSONArray struct {
+	Context       context.Context
+	Source        Source
+	FlushInterval time.Duration
+}
+
+var ndjsonContentType = []string{"application/x-ndjson"}
+
+// Render (NDJSON) writes the values of the source, one JSON document per line.
+func (r NDJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	enc := codec.GetJSON().NewEncoder(w)
+	return stream(r.Context, w, r.Source, r.FlushInterval, streamWriter{
+		value: func(v any) error {
+			return enc.Encode(v)
+		},
+	})
+}
+
+// WriteContentType (NDJSON) writes NDJSON ContentType.
+func (r NDJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, ndjsonContentType)
+}
+
+// Render (JSONArray) writes the values of the source as a JSON array.
+func (r JSONArray) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	n := 0
+	return stream(r.Context, w, r.Source, r.FlushInterval, streamWriter{
+		start: func() error {
+			_, err := w.Write([]byte{'['})
+			return err
+		},
+		value: func(v any) error {
+			buf := getBuffer()
+			defer putBuffer(buf)
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			n++
+			if err := encodeJSON(buf, v, true, ""); err != nil {
+				return err
+			}
+			_, err := w.Write(buf.Bytes())
+			return err
+		},
+		end: func() error {
+			_, err := w.Write([]byte{']'})
+			return err
+		},
+	})
+}
+
+// WriteContentType (JSONArray) writes JSON ContentType.
+func (r JSONArray) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// streamWriter writes the parts of a stream
+type streamWriter struct {
+	start func() error
+	value func(v any) error
+	end   func() error
+}
+
+// next is a result of Source.Next
+type next struct {
+	value any
+	ok    bool
+	err   error
+}
+
+// stream writes the values of the source. The source is read in a separate
+// goroutine, so the response is flushed periodically also while it blocks.
+// The goroutine asks the source for the next value only once the previous
+// value is written, signalled through demand.
+func stream(ctx context.Context, w http.ResponseWriter, src Source, interval time.Duration, sw streamWriter) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if interval == 0 {
+		interval = DefaultFlushInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	values := make(chan next)
+	demand := make(chan struct{}, 1)
+	demand <- struct{}{}
+	go func() {
+		for {
+			select {
+			case <-demand:
+			case <-ctx.Done():
+				return
+			}
+			v, ok, err := src.Next(ctx)
+			select {
+			case values <- next{value: v, ok: ok, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if !ok || err != nil {
+				return
+			}
+		}
+	}()
+
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	if sw.start != nil {
+		if err := sw.start(); err != nil {
+			return err
+		}
+	}
+	// the headers are sent right away, so the client knows the stream started
+	flush()
+	for {
+		select {
+		case <-ctx.Done():
+			// the client went away
+			return nil
+		case <-tick:
+			flush()
+		case n := <-values:
+			if n.err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return n.err
+			}
+			if !n.ok {
+				if sw.end != nil {
+					if err := sw.end(); err != nil {
+						return err
+					}
+				}
+				flush()
+				return nil
+			}
+			if err := sw.value(n.value); err != nil {
+				return err
+			}
+			// the value is written, the source may reuse it for the next one
+			demand <- struct{}{}
+			if interval < 0 {
+				flush()
+			}
+		}
+	}
+}
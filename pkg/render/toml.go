@@ -16,14 +16,12 @@ var TOMLContentType = []string{"application/toml; charset=utf-8"}
 // Render (TOML) marshals the given interface object and writes data with custom ContentType.
 func (r TOML) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
-
-	bytes, err := toml.Marshal(r.Data)
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := toml.NewEncoder(buf).Encode(r.Data); err != nil {
 		return err
 	}
-
-	_, err = w.Write(bytes)
-	return err
+	return writeBody(w, buf.Bytes())
 }
 
 // WriteContentType (TOML) writes TOML ContentType for response.
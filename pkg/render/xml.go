@@ -15,7 +15,12 @@ var xmlContentType = []string{"application/xml; charset=utf-8"}
 // Render (XML) encodes the given interface object and writes data with custom ContentType.
 func (r XML) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
-	return xml.NewEncoder(w).Encode(r.Data)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := xml.NewEncoder(buf).Encode(r.Data); err != nil {
+		return err
+	}
+	return writeBody(w, buf.Bytes())
 }
 
 // WriteContentType (XML) writes XML ContentType for response.
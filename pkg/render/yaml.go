@@ -16,14 +16,16 @@ var yamlContentType = []string{"application/x-yaml; charset=utf-8"}
 // Render (YAML) marshals the given interface object and writes data with custom ContentType.
 func (r YAML) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
-
-	bytes, err := yaml.Marshal(r.Data)
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	enc := yaml.NewEncoder(buf)
+	if err := enc.Encode(r.Data); err != nil {
 		return err
 	}
-
-	_, err = w.Write(bytes)
-	return err
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return writeBody(w, buf.Bytes())
 }
 
 // WriteContentType (YAML) writes YAML ContentType for response.
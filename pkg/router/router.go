@@ -6,11 +6,17 @@ package router
 // operation to lookup the respective handlers
 
 import (
+	"io/fs"
 	"net/http"
+	"os"
+	"path"
+	"strings"
 
 	"github.com/idproxy/httpserver/internal/utils"
 	"github.com/idproxy/httpserver/pkg/hctx"
 	"github.com/idproxy/httpserver/pkg/routetree"
+	"github.com/idproxy/httpserver/pkg/static"
+	"github.com/idproxy/httpserver/pkg/websocket"
 )
 
 const (
@@ -35,6 +41,16 @@ type Route interface {
 	PUT(string, ...hctx.HandlerFunc) Router
 	OPTIONS(string, ...hctx.HandlerFunc) Router
 	HEAD(string, ...hctx.HandlerFunc) Router
+	// WebSocket registers a GET route upgrading the requests to websocket
+	// connections with the upgrader, the default upgrader when nil.
+	WebSocket(string, *websocket.Upgrader, websocket.HandlerFunc) Router
+	// Static serves the files of the root directory under the relative path.
+	Static(string, string) Router
+	// StaticFS serves the files of the file system under the relative path,
+	// with the default config when nil.
+	StaticFS(string, fs.FS, *static.Config) Router
+	// StaticFile serves a single file of the local file system.
+	StaticFile(string, string) Router
 
 	// internal
 	getAbsolutePath(relativePath string) string
@@ -60,7 +76,7 @@ type router struct {
 	routes   routetree.Routes
 }
 
-func (r *router) GetHandlers()  hctx.HandlerChain {
+func (r *router) GetHandlers() hctx.HandlerChain {
 	return r.handlers
 }
 
@@ -113,6 +129,54 @@ func (r *router) HEAD(relativePath string, handlers ...hctx.HandlerFunc) Router
 	return r.add(http.MethodHead, relativePath, hctx.New(handlers...))
 }
 
+// WebSocket registers a GET route upgrading the requests to websocket
+// connections and calling handler with the connection.
+func (r *router) WebSocket(relativePath string, upgrader *websocket.Upgrader, handler websocket.HandlerFunc) Router {
+	return r.add(http.MethodGet, relativePath, hctx.New(websocket.Handler(upgrader, handler)))
+}
+
+// Static serves the files of the root directory of the local file system
+// under the relative path, for example:
+//
+//	router.Static("/static", "/var/www")
+func (r *router) Static(relativePath, root string) Router {
+	return r.StaticFS(relativePath, os.DirFS(root), nil)
+}
+
+// StaticFS serves the files of the file system, like an embed.FS, under the
+// relative path. It registers GET and HEAD routes on a catch-all path segment,
+// and on the relative path itself, which the catch-all does not match: it
+// serves the index of the root for "/" and redirects to the path with a
+// trailing slash otherwise.
+func (r *router) StaticFS(relativePath string, fsys fs.FS, cfg *static.Config) Router {
+	if strings.ContainsAny(relativePath, ":*") {
+		panic("URL parameters can not be used when serving a static folder")
+	}
+	urlPattern := path.Join(relativePath, "/*"+static.Param)
+	rootPath := strings.TrimSuffix(relativePath, "/")
+	handler := static.Handler(fsys, cfg)
+	r.GET(urlPattern, handler)
+	r.HEAD(urlPattern, handler)
+	r.GET(rootPath, handler)
+	r.HEAD(rootPath, handler)
+	return r
+}
+
+// StaticFile registers a single route in order to serve a single file of the local file system.
+//
+//	router.StaticFile("/favicon.ico", "./resources/favicon.ico")
+func (r *router) StaticFile(relativePath, filepath string) Router {
+	if strings.ContainsAny(relativePath, ":*") {
+		panic("URL parameters can not be used when serving a static file")
+	}
+	handler := func(c hctx.Context) {
+		c.File(filepath)
+	}
+	r.GET(relativePath, handler)
+	r.HEAD(relativePath, handler)
+	return r
+}
+
 // Any registers a route that matches all the HTTP methods.
 // GET, POST, PUT, PATCH, HEAD, OPTIONS, DELETE, CONNECT, TRACE.
 func (r *router) Any(relativePath string, handlers ...hctx.HandlerFunc) Router {
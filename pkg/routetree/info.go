@@ -0,0 +1,68 @@
+package routetree
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/idproxy/httpserver/pkg/hctx"
+)
+
+// RouteInfo describes a registered route. Request and Response are the types
+// of the typed handler serving the route, nil for untyped handlers.
+type RouteInfo struct {
+	Method   string
+	Path     string
+	Handlers int
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// List returns the registered routes sorted by path and method
+func (r *routes) List() []RouteInfo {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	var infos []RouteInfo
+	for method, n := range r.routes {
+		infos = n.list(method, "/", infos)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+func (r *node) list(method, path string, infos []RouteInfo) []RouteInfo {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	if r.handlers != nil && r.handlers.Size() > 0 {
+		info := RouteInfo{
+			Method:   method,
+			Path:     path,
+			Handlers: r.handlers.Size(),
+		}
+		if types, ok := hctx.TypesOf(r.handlers); ok {
+			info.Request = types.Request
+			info.Response = types.Response
+		}
+		infos = append(infos, info)
+	}
+	for _, n := range r.children {
+		infos = n.list(method, joinPath(path, n.PathSegment.Value), infos)
+	}
+	return infos
+}
+
+// joinPath appends a path segment to the path, a "/" segment is a trailing slash
+func joinPath(path, segment string) string {
+	switch {
+	case path == "/":
+		return path + segment
+	case segment == "/":
+		return path + "/"
+	default:
+		return path + "/" + segment
+	}
+}
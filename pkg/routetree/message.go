@@ -1,7 +1,24 @@
 package routetree
 
+import "net/http"
+
 var (
 	default400Body = []byte("400 bad request")
 	default404Body = []byte("404 page not found")
-	//default405Body = []byte("405 method not allowed")
-)
\ No newline at end of file
+	default405Body = []byte("405 method not allowed")
+)
+
+// Message returns the default body of the responses with the status written
+// by the router, or "" for the other statuses.
+func Message(code int) string {
+	switch code {
+	case http.StatusBadRequest:
+		return string(default400Body)
+	case http.StatusNotFound:
+		return string(default404Body)
+	case http.StatusMethodNotAllowed:
+		return string(default405Body)
+	default:
+		return ""
+	}
+}
@@ -3,6 +3,7 @@ package routetree
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/idproxy/httpserver/internal/pathsegment"
@@ -33,8 +34,15 @@ func (r *node) Print(i int) {
 func (r *node) addroute(idx int, pathSegments pathsegment.PathSegments, handlers hctx.HandlerChain) error {
 	ps := pathSegments.Get(idx)
 	psValue := ps.Value
-	if ps.Kind == pathsegment.Param || ps.Kind == pathsegment.CatchAll {
+	switch ps.Kind {
+	case pathsegment.Param:
 		psValue = wildcard
+	case pathsegment.CatchAll:
+		// a catch-all matches the rest of the path
+		if idx != pathSegments.Size()-1 {
+			return fmt.Errorf("catch-all: %s must be the last path segment", ps.Value)
+		}
+		psValue = catchAll
 	}
 	n, ok := r.children[psValue]
 	if !ok {
@@ -44,10 +52,8 @@ func (r *node) addroute(idx int, pathSegments pathsegment.PathSegments, handlers
 		}
 		r.children[psValue] = n
 	}
-	if ok && psValue == wildcard {
-		if !ok {
-			return fmt.Errorf("got wildcard: %s, but another wildcard was already in place: %s", ps.Value, n.PathSegment.Value)
-		}
+	if ok && (psValue == wildcard || psValue == catchAll) && n.PathSegment.Value != ps.Value {
+		return fmt.Errorf("got wildcard: %s, but another wildcard was already in place: %s", ps.Value, n.PathSegment.Value)
 	}
 	fmt.Printf("routes addRoute: pathSegments: %v, idx: %d ps length: %d\n", pathSegments, idx, pathSegments.Size()-1)
 	if idx == pathSegments.Size()-1 {
@@ -62,48 +68,82 @@ func (r *node) addroute(idx int, pathSegments pathsegment.PathSegments, handlers
 // dynamic processing per http request
 
 func (r *node) GetRouteContext(hctx hctx.Context) {
+	node, ps := r.lookup(hctx.GetPathSegments(), hctx.GetPathSegmentIndex())
+	if node == nil {
+		hctx.SetStatus(http.StatusNotFound)
+		hctx.SetMessage(string(default404Body))
+		return
+	}
+	// add the values of the wildcards to the parameter list
+	for _, p := range ps {
+		hctx.GetParams().Add(p)
+	}
+	hctx.SetHandlers(node.handlers)
+}
+
+// lookup returns the node with handlers matching the pathSegments from idx,
+// and the params of the wildcards on the way. Static path segments take
+// precedence over params, and params over catch-alls.
+func (r *node) lookup(pathSegments pathsegment.PathSegments, idx int) (*node, []params.Param) {
 	r.m.RLock()
 	defer r.m.RUnlock()
-	node, ok := r.children[hctx.GetPathSegments().Get(hctx.GetPathSegmentIndex()).Value]
-	if !ok {
-		fmt.Printf("getValue: children %v\n", r.children)
-		fmt.Printf("getValue: not found -> %s\n", hctx.GetPathSegments().Get(hctx.GetPathSegmentIndex()).Value)
-		// if the node was not found we need to validate if there is a wildcard
-		node, ok = r.children[wildcard]
-		if !ok {
-			// no wildcard found in this pathSegment
-			hctx.SetStatus(http.StatusNotFound)
-			hctx.SetMessage(string(default404Body))
-			return
+	value := pathSegments.Get(idx).Value
+	last := idx == pathSegments.Size()-1
+
+	if node, ok := r.children[value]; ok {
+		if last && node.hasHandlers() {
+			return node, nil
+		}
+		if !last {
+			if n, ps := node.lookup(pathSegments, idx+1); n != nil {
+				return n, ps
+			}
 		}
-		// wildcard exists for the pathSegment
-		// add the param KeyValue to the parameter list
-		//fmt.Printf("params: %v\n", hctx.GetParams())
-		hctx.GetParams().Add(params.Param{
+	}
+	if node, ok := r.children[wildcard]; ok {
+		p := params.Param{Key: node.PathSegment.Value[1:], Value: value}
+		if last && node.hasHandlers() {
+			return node, []params.Param{p}
+		}
+		if !last {
+			if n, ps := node.lookup(pathSegments, idx+1); n != nil {
+				return n, append(ps, p)
+			}
+		}
+	}
+	if node, ok := r.children[catchAll]; ok && node.hasHandlers() {
+		return node, []params.Param{{
 			Key:   node.PathSegment.Value[1:],
-			Value: hctx.GetPathSegments().Get(hctx.GetPathSegmentIndex()).Value,
-		})
-		//fmt.Printf("params: %v\n", hctx.GetParams().List())
+			Value: catchAllValue(pathSegments, idx),
+		}}
 	}
+	return nil, nil
+}
 
-	fmt.Printf("getValue: pathSegments %v pathSegmentIdx: %d total ps length: %d\n",
-		hctx.GetPathSegments(),
-		hctx.GetPathSegmentIndex(),
-		hctx.GetPathSegments().Size()-1)
-	if hctx.GetPathSegmentIndex() == hctx.GetPathSegments().Size()-1 {
-		/*
-			if r.handlers != nil {
-				fmt.Printf("getValue: handlers: %v\n", r.handlers.Size())
-			}
-		*/
+// match returns true when a route with handlers matches the pathSegments,
+// without updating the context of the request
+func (r *node) match(pathSegments pathsegment.PathSegments, idx int) bool {
+	n, _ := r.lookup(pathSegments, idx)
+	return n != nil
+}
 
-		hctx.SetHandlers(node.handlers)
-		if node.handlers == nil || node.handlers.Size() == 0 {
-			hctx.SetStatus(http.StatusNotFound)
-			hctx.SetMessage(string(default404Body))
+func (r *node) hasHandlers() bool {
+	return r.handlers != nil && r.handlers.Size() > 0
+}
+
+// catchAllValue returns the rest of the path from the path segment idx,
+// starting with a "/"
+func catchAllValue(pathSegments pathsegment.PathSegments, idx int) string {
+	var b strings.Builder
+	for i := idx; i < pathSegments.Size(); i++ {
+		value := pathSegments.Get(i).Value
+		// the last "/" path segment is the trailing slash
+		if value == "/" && i == pathSegments.Size()-1 {
+			b.WriteByte('/')
+			break
 		}
-		return
+		b.WriteByte('/')
+		b.WriteString(value)
 	}
-	hctx.IncrementPathSegmentIndex()
-	node.GetRouteContext(hctx)
+	return b.String()
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/idproxy/httpserver/internal/pathsegment"
@@ -12,6 +13,7 @@ import (
 
 const (
 	wildcard = "wildcard"
+	catchAll = "catchAll"
 )
 
 // Routes define the
@@ -25,6 +27,8 @@ type Routes interface {
 
 	// helper functions
 	Print()
+	// List returns the registered routes sorted by path and method
+	List() []RouteInfo
 	GetSupportedmethods() []string
 }
 
@@ -140,6 +144,7 @@ func (r *routes) GetRouteContext(hctx hctx.Context) {
 		// httpMethod not found
 		hctx.SetStatus(http.StatusNotFound)
 		hctx.SetMessage(string(default404Body))
+		r.methodNotAllowed(hctx, pathSegments)
 		return
 	}
 	if pathSegments.Size() == 1 {
@@ -149,6 +154,7 @@ func (r *routes) GetRouteContext(hctx hctx.Context) {
 		if n.handlers == nil || n.handlers.Size() == 0 {
 			hctx.SetStatus(http.StatusNotFound)
 			hctx.SetMessage(string(default404Body))
+			r.methodNotAllowed(hctx, pathSegments)
 		}
 		return
 	}
@@ -156,4 +162,35 @@ func (r *routes) GetRouteContext(hctx hctx.Context) {
 	hctx.SetPathSegments(pathSegments)
 	hctx.SetPathSegmentIndex(1)
 	n.GetRouteContext(hctx)
+	if hctx.GetStatus() == http.StatusNotFound {
+		r.methodNotAllowed(hctx, pathSegments)
+	}
+}
+
+// methodNotAllowed updates the context to a 405 response, with the Allow
+// header listing the methods of the routes matching the path, when the path
+// only matches routes of other methods.
+func (r *routes) methodNotAllowed(hctx hctx.Context, pathSegments pathsegment.PathSegments) {
+	var allowed []string
+	for _, method := range r.supportedMethods {
+		if method == hctx.GetMethod() {
+			continue
+		}
+		n := r.routes[method]
+		if pathSegments.Size() == 1 {
+			if n.handlers != nil && n.handlers.Size() > 0 {
+				allowed = append(allowed, method)
+			}
+			continue
+		}
+		if n.match(pathSegments, 1) {
+			allowed = append(allowed, method)
+		}
+	}
+	if len(allowed) == 0 {
+		return
+	}
+	hctx.Writer().Header().Set("Allow", strings.Join(allowed, ", "))
+	hctx.SetStatus(http.StatusMethodNotAllowed)
+	hctx.SetMessage(string(default405Body))
 }
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"html/template"
+	"io/fs"
+
+	"github.com/idproxy/httpserver/pkg/render"
+)
+
+// Delims sets the left and right delimiters of the HTML templates loaded afterwards.
+func (r *server) Delims(left, right string) Server {
+	r.delims = render.Delims{Left: left, Right: right}
+	return r
+}
+
+// SetFuncMap sets the FuncMap of the HTML templates loaded afterwards.
+func (r *server) SetFuncMap(funcMap template.FuncMap) {
+	r.funcMap = funcMap
+}
+
+// LoadHTMLGlob loads the HTML templates matching the glob pattern.
+// In debug mode the templates are reloaded on every render.
+func (r *server) LoadHTMLGlob(pattern string) {
+	templ := template.Must(r.newTemplate().ParseGlob(pattern))
+	if r.cfg.Debug {
+		r.htmlRender = render.HTMLDebug{Glob: pattern, FuncMap: r.funcMap, Delims: r.delims}
+		return
+	}
+	r.SetHTMLTemplate(templ)
+}
+
+// LoadHTMLFiles loads the HTML template files.
+// In debug mode the templates are reloaded on every render.
+func (r *server) LoadHTMLFiles(files ...string) {
+	templ := template.Must(r.newTemplate().ParseFiles(files...))
+	if r.cfg.Debug {
+		r.htmlRender = render.HTMLDebug{Files: files, FuncMap: r.funcMap, Delims: r.delims}
+		return
+	}
+	r.SetHTMLTemplate(templ)
+}
+
+// LoadHTMLFS loads the HTML templates of the file system matching the patterns,
+// e.g. an embed.FS. In debug mode the templates are reloaded on every render.
+func (r *server) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	templ := template.Must(r.newTemplate().ParseFS(fsys, patterns...))
+	if r.cfg.Debug {
+		r.htmlRender = render.HTMLDebug{FS: fsys, Patterns: patterns, FuncMap: r.funcMap, Delims: r.delims}
+		return
+	}
+	r.SetHTMLTemplate(templ)
+}
+
+// SetHTMLTemplate associates the template with the HTML render.
+func (r *server) SetHTMLTemplate(templ *template.Template) {
+	r.htmlRender = render.HTMLProduction{Template: templ.Funcs(r.funcMap)}
+}
+
+// SetHTMLRender sets the render used by hctx.Context.HTML.
+func (r *server) SetHTMLRender(htmlRender render.HTMLRender) {
+	r.htmlRender = htmlRender
+}
+
+func (r *server) newTemplate() *template.Template {
+	return template.New("").Delims(r.delims.Left, r.delims.Right).Funcs(r.funcMap)
+}
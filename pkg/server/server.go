@@ -1,32 +1,133 @@
 package server
 
 import (
+	"context"
+	"html/template"
+	"io/fs"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/idproxy/httpserver/pkg/binding"
 	"github.com/idproxy/httpserver/pkg/hctx"
 	"github.com/idproxy/httpserver/pkg/params"
+	"github.com/idproxy/httpserver/pkg/render"
 	"github.com/idproxy/httpserver/pkg/router"
 	"github.com/idproxy/httpserver/pkg/routetree"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
 
+const (
+	defaultShutdownTimeout = 30 * time.Second
+	defaultUpgradeTimeout  = time.Minute
+	defaultMaxBodySize     = 32 << 20
+)
+
 type Server interface {
+	// Handler returns the http.Handler serving the routes, wrapped for h2c
+	// when UseH2C is configured.
+	Handler() http.Handler
+	// ServeHTTP serves the request through the routes, without h2c support.
+	ServeHTTP(w http.ResponseWriter, req *http.Request)
 	Router() router.Router
+	// Run listens on the address, or on the listener handed over by the parent
+	// process during an upgrade, and serves requests until the server is shut down.
 	Run(address string) error
+	// Shutdown gracefully stops the server, waiting for in-flight requests
+	// to complete until the context is done.
+	Shutdown(ctx context.Context) error
+	// Upgrade hands the listener to a freshly started copy of the running binary
+	// and shuts down gracefully once the new process reports it is ready.
+	Upgrade() error
 	PrintRoutes()
+	// Delims sets the left and right delimiters of the HTML templates loaded afterwards.
+	Delims(left, right string) Server
+	// SetFuncMap sets the FuncMap of the HTML templates loaded afterwards.
+	SetFuncMap(funcMap template.FuncMap)
+	// LoadHTMLGlob loads the HTML templates matching the glob pattern.
+	LoadHTMLGlob(pattern string)
+	// LoadHTMLFiles loads the HTML template files.
+	LoadHTMLFiles(files ...string)
+	// LoadHTMLFS loads the HTML templates of the file system matching the patterns.
+	LoadHTMLFS(fsys fs.FS, patterns ...string)
+	// SetHTMLTemplate associates the template with the HTML render.
+	SetHTMLTemplate(templ *template.Template)
+	// SetHTMLRender sets the render used by hctx.Context.HTML.
+	SetHTMLRender(htmlRender render.HTMLRender)
+	// Routes returns the registered routes with the request and response
+	// types of the typed handlers serving them.
+	Routes() []routetree.RouteInfo
 }
 
 type Config struct {
+	// UseRawPath if enabled, the url.RawPath will be used to find parameters.
+	UseRawPath bool
+	// UnescapePathValues if true, the path value will be unescaped.
+	// If UseRawPath is false (by default), the UnescapePathValues effectively is true,
+	// as url.Path gonna be used, which is already unescaped.
+	UnescapePathValues bool
+	// UseH2C enables h2c support, serving HTTP/2 without TLS.
+	UseH2C bool
+	// MaxBodySize limits the size of the request body read when binding.
+	// Defaults to 32 MiB, a negative value disables the limit.
+	MaxBodySize int64
+	// MaxMultipartMemory is the part of a multipart body kept in memory,
+	// the remainder of the files is stored in temporary files. Defaults to 32 MiB.
+	MaxMultipartMemory int64
+	// MaxUploadSize limits the size of a multipart body. Defaults to MaxBodySize,
+	// a negative value disables the limit.
+	MaxUploadSize int64
+	// MaxUploadFileSize limits the size of every file in a multipart body.
+	// Defaults to no limit.
+	MaxUploadFileSize int64
+	// Debug reloads the HTML templates on every render, so changes are
+	// visible without restarting the server.
+	Debug bool
+	// SecureJSONPrefix is prepended to the responses rendered by
+	// hctx.Context.SecureJSON. Defaults to hctx.DefaultSecureJSONPrefix.
+	SecureJSONPrefix string
+	// ErrorHandler is called after the handlers of a request are executed
+	// when errors were attached to the context, see hctx.Context.Error.
+	// It translates the collected errors in a consistent response, using
+	// hctx.Context.Writer().Written() to check if a response was already written.
+	// Defaults to DefaultErrorHandler.
+	ErrorHandler hctx.HandlerFunc
+	// ShutdownTimeout bounds how long a graceful shutdown triggered by a signal
+	// or an upgrade waits for in-flight requests. Defaults to 30 seconds.
+	ShutdownTimeout time.Duration
+	// UpgradeTimeout bounds how long an upgrade waits for the new process to
+	// report it is ready before the upgrade is abandoned. Defaults to 1 minute.
+	UpgradeTimeout time.Duration
 }
 
 func New() Server {
+	return NewWithConfig(Config{})
+}
+
+func NewWithConfig(cfg Config) Server {
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if cfg.UpgradeTimeout == 0 {
+		cfg.UpgradeTimeout = defaultUpgradeTimeout
+	}
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = defaultMaxBodySize
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
 	routes := routetree.New()
 	router := router.New(routes)
 	s := &server{
-		routes: routes,
-		router: router,
+		cfg:                cfg,
+		routes:             routes,
+		router:             router,
+		UseRawPath:         cfg.UseRawPath,
+		UnescapePathValues: cfg.UnescapePathValues,
+		useH2C:             cfg.UseH2C,
 	}
 	s.pool.New = func() any {
 		return s.allocateContext()
@@ -35,6 +136,7 @@ func New() Server {
 }
 
 type server struct {
+	cfg    Config
 	routes routetree.Routes
 	router router.Router // used to add routes to the route tree
 
@@ -49,7 +151,16 @@ type server struct {
 	// UseH2C enable h2c support.
 	useH2C bool
 
+	// HTML template rendering
+	delims     render.Delims
+	funcMap    template.FuncMap
+	htmlRender render.HTMLRender
+
 	pool sync.Pool
+
+	// m protects run, the state of the running http server
+	m   sync.Mutex
+	run *runState
 }
 
 func (r *server) Use(middleware ...hctx.HandlerFunc) router.Router {
@@ -67,6 +178,12 @@ func (r *server) PrintRoutes() {
 	r.routes.Print()
 }
 
+func (r *server) Routes() []routetree.RouteInfo {
+	return r.routes.List()
+}
+
+// Handler returns the http.Handler serving the routes. When h2c is enabled
+// the server is wrapped to serve HTTP/2 over cleartext connections.
 func (r *server) Handler() http.Handler {
 	if !r.useH2C {
 		return r
@@ -76,10 +193,16 @@ func (r *server) Handler() http.Handler {
 }
 
 // Run attaches the router to a http.Server and starts listening and serving HTTP requests.
-// It is a shortcut for http.ListenAndServe(addr, router)
-// Note: this method will block the calling goroutine indefinitely unless an error happens.
+// When the process was started by Upgrade the listener of the parent is reused.
+// SIGINT and SIGTERM trigger a graceful shutdown, SIGUSR2 triggers an Upgrade.
+// Note: this method will block the calling goroutine until the server is shut down
+// or an error happens.
 func (r *server) Run(address string) error {
-	return http.ListenAndServe(address, r.Handler())
+	ln, err := listen(address)
+	if err != nil {
+		return err
+	}
+	return r.serve(ln)
 }
 
 // ServeHTTP implements the http.Handler interface.
@@ -94,9 +217,24 @@ func (r *server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		Writer:             w,
 		UseRawPath:         r.UseRawPath,
 		UnescapePathValues: r.UnescapePathValues,
+		MaxBodySize:        r.cfg.MaxBodySize,
+		MaxMultipartMemory: r.cfg.MaxMultipartMemory,
+		MaxUploadSize:      r.cfg.MaxUploadSize,
+		MaxUploadFileSize:  r.cfg.MaxUploadFileSize,
+		SecureJSONPrefix:   r.cfg.SecureJSONPrefix,
+		HTMLRender:         r.htmlRender,
 	})
 
 	r.handleHTTPRequest(ctx)
+	// write the status when the handlers did not write a body
+	ctx.Writer().WriteHeaderNow()
+	// the response is complete, e.g. the logger reads its status and size
+	ctx.Finish()
+	// the http server only removes the temporary files of the multipart
+	// form parsed on the original request
+	if creq := ctx.GetRequest(); creq != req && creq.MultipartForm != nil {
+		creq.MultipartForm.RemoveAll()
+	}
 
 	r.pool.Put(ctx)
 }
@@ -111,6 +249,7 @@ func (r *server) handleHTTPRequest(hctx hctx.Context) {
 
 	if hctx.GetHandlers() != nil && hctx.GetHandlers().Size() > 0 {
 		hctx.Next()
+		r.handleErrors(hctx)
 		return
 	}
 
@@ -131,11 +270,62 @@ func (r *server) handleHTTPRequest(hctx hctx.Context) {
 	hctx.SetHandlers(r.Router().GetHandlers())
 	if hctx.GetHandlers() != nil && hctx.GetHandlers().Size() > 0 {
 		hctx.Next()
+		r.handleErrors(hctx)
 	}
 	serveError(hctx)
 }
 
+// handleErrors calls the error handler when errors were collected during
+// the processing of the request
+func (r *server) handleErrors(hctx hctx.Context) {
+	if r.cfg.ErrorHandler == nil || len(hctx.Errors()) == 0 {
+		return
+	}
+	r.cfg.ErrorHandler(hctx)
+}
+
+// DefaultErrorHandler is the ErrorHandler used when none is configured.
+// When the handlers did not write a response it writes the status of the
+// response, 500 when that is not an error status, with the messages of the
+// public errors as problem details when the client prefers JSON over plain text.
+func DefaultErrorHandler(c hctx.Context) {
+	if c.Writer().Written() {
+		return
+	}
+	code := c.Writer().Status()
+	if code < http.StatusBadRequest {
+		code = http.StatusInternalServerError
+	}
+	detail := strings.Join(c.Errors().ByType(hctx.ErrorTypePublic).Errors(), "; ")
+	writeError(c, code, detail)
+}
+
+// serveError writes the default response of the status of the request, as
+// problem details when the client prefers JSON over plain text.
 func serveError(hctx hctx.Context) {
+	// a middleware already wrote the response
+	if hctx.Writer().Written() {
+		return
+	}
+	writeError(hctx, hctx.GetStatus(), hctx.GetMessage())
+}
 
-	hctx.String(hctx.GetStatus(), hctx.GetMessage())
+// writeError writes the error response with the status and the message, as
+// problem details when the client prefers JSON over plain text. Without a
+// message the body of the router is used, e.g. for a file that does not exist.
+func writeError(c hctx.Context, code int, message string) {
+	if message == "" {
+		message = routetree.Message(code)
+	}
+	switch c.NegotiateFormat(binding.MIMEPlain, render.ProblemContentType, binding.MIMEJSON) {
+	case render.ProblemContentType, binding.MIMEJSON:
+		problem := render.NewProblem(code, message)
+		problem.Instance = c.GetRequestPath()
+		c.Render(code, problem)
+	default:
+		if message == "" {
+			message = http.StatusText(code)
+		}
+		c.String(code, "%s", message)
+	}
 }
@@ -0,0 +1,587 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/idproxy/httpserver/pkg/hctx"
+	"github.com/idproxy/httpserver/pkg/middleware/logger"
+	"github.com/idproxy/httpserver/pkg/render"
+	"github.com/idproxy/httpserver/pkg/static"
+	"github.com/idproxy/httpserver/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+)
+
+func TestServerHandler(t *testing.T) {
+	s := New()
+	s.Router().GET("/ping", func(hctx hctx.Context) {
+		hctx.String(http.StatusOK, "pong")
+	})
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/ping")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "pong", string(body))
+}
+
+func TestServerHandlerMountedOnMux(t *testing.T) {
+	s := New()
+	s.Router().GET("/api/ping", func(hctx hctx.Context) {
+		hctx.String(http.StatusOK, "pong")
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/api/", s.Handler())
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "pong", w.Body.String())
+}
+
+func TestServerHandlerH2C(t *testing.T) {
+	s := NewWithConfig(Config{UseH2C: true})
+	s.Router().GET("/proto", func(hctx hctx.Context) {
+		hctx.String(http.StatusOK, hctx.GetRequest().Proto)
+	})
+
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+	resp, err := client.Get(ts.URL + "/proto")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", string(body))
+}
+
+func TestServerErrorHandler(t *testing.T) {
+	s := NewWithConfig(Config{
+		ErrorHandler: func(c hctx.Context) {
+			c.JSON(http.StatusInternalServerError, c.Errors().ByType(hctx.ErrorTypePublic))
+		},
+	})
+	s.Router().GET("/fail", func(c hctx.Context) {
+		c.Error(errors.New("internal detail"))
+		c.Error(errors.New("something went wrong")).SetType(hctx.ErrorTypePublic)
+	})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"error":"something went wrong"}`, w.Body.String())
+}
+
+func TestServerDefaultErrorHandler(t *testing.T) {
+	s := New()
+	s.Router().GET("/fail", func(c hctx.Context) {
+		c.Error(errors.New("internal detail"))
+		c.AbortWithError(http.StatusConflict, errors.New("user exists")).SetType(hctx.ErrorTypePublic)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Conflict",
+		"status": 409,
+		"detail": "user exists",
+		"instance": "/fail"
+	}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "user exists", w.Body.String())
+}
+
+func TestServerFileNotFound(t *testing.T) {
+	s := New()
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	s.Router().GET("/file", func(c hctx.Context) {
+		c.File(missing)
+	})
+	s.Router().GET("/download", func(c hctx.Context) {
+		c.FileAttachment(missing, "report.txt")
+	})
+
+	for _, target := range []string{"/file", "/download"} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, target)
+		assert.Equal(t, "404 page not found", w.Body.String(), target)
+		assert.Empty(t, w.Header().Get("Content-Disposition"), target)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "404 page not found",
+		"instance": "/file"
+	}`, w.Body.String())
+}
+
+func TestServerLoggerAfterErrorHandler(t *testing.T) {
+	s := NewWithConfig(Config{
+		ErrorHandler: func(c hctx.Context) {
+			c.String(http.StatusServiceUnavailable, "unavailable")
+		},
+	})
+	var logged logger.LogFormatterParams
+	s.Router().Use(logger.LoggerWithConfig(logger.LoggerConfig{
+		Output: io.Discard,
+		Formatter: func(p logger.LogFormatterParams) string {
+			logged = p
+			return ""
+		},
+	}))
+	s.Router().GET("/fail", func(c hctx.Context) {
+		c.Error(errors.New("backend down"))
+	})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	// the logger observes the response written by the error handler
+	assert.Equal(t, http.StatusServiceUnavailable, logged.StatusCode)
+	assert.Equal(t, len("unavailable"), logged.BodySize)
+	assert.Equal(t, "Error #01: backend down\n", logged.ErrorMessage)
+}
+
+func TestServerRoutes(t *testing.T) {
+	type getUserRequest struct {
+		Name string `uri:"name"`
+	}
+	type user struct {
+		Name string `json:"name"`
+	}
+	s := New()
+	s.Router().GET("/ping", func(c hctx.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	auth := func(c hctx.Context) {}
+	s.Router().GET("/users/:name", auth, hctx.Typed(func(c hctx.Context, req getUserRequest) (user, error) {
+		return user{Name: req.Name}, nil
+	}))
+
+	routes := s.Routes()
+	assert.Len(t, routes, 2)
+	assert.Equal(t, 2, routes[1].Handlers)
+	assert.Equal(t, "/ping", routes[0].Path)
+	assert.Nil(t, routes[0].Request)
+	assert.Equal(t, http.MethodGet, routes[1].Method)
+	assert.Equal(t, "/users/:name", routes[1].Path)
+	assert.Equal(t, "getUserRequest", routes[1].Request.Name())
+	assert.Equal(t, "user", routes[1].Response.Name())
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/alice", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"name":"alice"}`, w.Body.String())
+}
+
+func TestServerLoadHTML(t *testing.T) {
+	s := New()
+	s.Delims("{[{", "}]}")
+	s.LoadHTMLGlob("../testdata/template/hello*")
+	s.Router().GET("/hello", func(c hctx.Context) {
+		c.HTML(http.StatusOK, "hello.tmpl", map[string]string{"name": "alice"})
+	})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<h1>Hello alice</h1>", w.Body.String())
+}
+
+func TestServerLoadHTMLFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/index.tmpl": {Data: []byte(`{{upper .}}`)},
+	}
+	s := New()
+	s.SetFuncMap(template.FuncMap{"upper": strings.ToUpper})
+	s.LoadHTMLFS(fsys, "templates/*.tmpl")
+	s.Router().GET("/", func(c hctx.Context) {
+		c.HTML(http.StatusOK, "index.tmpl", "alice")
+	})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "ALICE", w.Body.String())
+}
+
+func TestServerLoadHTMLDebug(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "index.tmpl")
+	assert.NoError(t, os.WriteFile(file, []byte("v1"), 0o600))
+
+	s := NewWithConfig(Config{Debug: true})
+	s.LoadHTMLFiles(file)
+	s.Router().GET("/", func(c hctx.Context) {
+		c.HTML(http.StatusOK, "index.tmpl", nil)
+	})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "v1", w.Body.String())
+
+	assert.NoError(t, os.WriteFile(file, []byte("v2"), 0o600))
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "v2", w.Body.String())
+}
+
+func TestServerStreamNDJSON(t *testing.T) {
+	done := make(chan struct{})
+	s := New()
+	s.Router().GET("/events", func(c hctx.Context) {
+		defer close(done)
+		i := 0
+		c.StreamNDJSON(http.StatusOK, render.SourceFunc(func(ctx context.Context) (any, bool, error) {
+			i++
+			select {
+			case <-time.After(time.Millisecond):
+				return map[string]int{"n": i}, true, nil
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+		}))
+	})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"n\":1}\n", line)
+	// the stream ends when the client goes away
+	resp.Body.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not stop after the client went away")
+	}
+}
+
+func TestServerSSE(t *testing.T) {
+	done := make(chan struct{})
+	s := New()
+	s.Router().GET("/sse", func(c hctx.Context) {
+		defer close(done)
+		events := make(chan render.SSEvent)
+		go func() {
+			events <- render.SSEvent{ID: c.LastEventID() + "1", Event: "tick", Data: "hello"}
+		}()
+		c.SSE(events, 10*time.Millisecond)
+	})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/sse", nil)
+	req.Header.Set("Last-Event-ID", "4")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	r := bufio.NewReader(resp.Body)
+	var frame []string
+	for len(frame) < 4 {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		frame = append(frame, line)
+	}
+	assert.Equal(t, []string{"id: 41\n", "event: tick\n", "data: hello\n", "\n"}, frame)
+	line, err := r.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, ": keep-alive\n", line)
+
+	resp.Body.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SSE did not stop after the client went away")
+	}
+}
+
+func TestServerStream(t *testing.T) {
+	s := New()
+	s.Router().GET("/count", func(c hctx.Context) {
+		i := 0
+		c.Stream(func(w io.Writer) bool {
+			i++
+			io.WriteString(w, strconv.Itoa(i))
+			return i < 3
+		})
+	})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/count", nil))
+	assert.Equal(t, "123", w.Body.String())
+	assert.True(t, w.Flushed)
+}
+
+func TestServerWebSocket(t *testing.T) {
+	s := New()
+	s.Router().WebSocket("/ws/:room", nil, func(c hctx.Context, conn *websocket.Conn) {
+		room, _ := c.GetParams().Get("room")
+		for {
+			_, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(room+": "+string(p)))
+		}
+	})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	conn, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/ws/lobby", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, p, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "lobby: hello", string(p))
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws/lobby", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServerDefaultErrors(t *testing.T) {
+	s := New()
+	s.Router().GET("/users", func(c hctx.Context) {
+		c.String(http.StatusOK, "users")
+	})
+	s.Router().POST("/users", func(c hctx.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "404 page not found", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set("Accept", "application/json")
+	s.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "404 page not found",
+		"instance": "/missing"
+	}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, POST", w.Header().Get("Allow"))
+	assert.Equal(t, "405 method not allowed", w.Body.String())
+}
+
+func TestServerCatchAll(t *testing.T) {
+	s := New()
+	handler := func(name string) hctx.HandlerFunc {
+		return func(c hctx.Context) {
+			c.String(http.StatusOK, "%s %v", name, c.GetParams().List())
+		}
+	}
+	s.Router().GET("/files/*path", handler("catchAll"))
+	s.Router().GET("/files/:id/meta", handler("param"))
+	s.Router().GET("/files/latest", handler("static"))
+
+	tests := map[string]string{
+		"/files/a":          "catchAll map[path:/a]",
+		"/files/a/b/c.txt":  "catchAll map[path:/a/b/c.txt]",
+		"/files/a/b/":       "catchAll map[path:/a/b/]",
+		"/files/":           "catchAll map[path:/]",
+		"/files/a/meta":     "param map[id:a]",
+		"/files/latest":     "static map[]",
+		"/files/latest/x/y": "catchAll map[path:/latest/x/y]",
+	}
+	for target, want := range tests {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusOK, w.Code, target)
+		assert.Equal(t, want, w.Body.String(), target)
+	}
+
+	assert.Panics(t, func() {
+		s.Router().GET("/bad/*path/more", handler("bad"))
+	})
+}
+
+func TestServerStatic(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "robots.txt"), []byte("User-agent: *"), 0o600))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "docs"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "a&b.txt"), []byte("a"), 0o600))
+
+	fsys := fstest.MapFS{
+		"index.html":     {Data: []byte("<h1>app</h1>")},
+		"app.js":         {Data: []byte("console.log(1)")},
+		"app.js.gz":      {Data: []byte("gzipped")},
+		"app.js.br":      {Data: []byte("brotli")},
+		"assets/logo.sv": {Data: []byte("logo")},
+	}
+
+	s := New()
+	s.Router().Static("/public", dir)
+	s.Router().StaticFS("/app", fsys, &static.Config{SPA: true})
+	s.Router().StaticFS("/browse", os.DirFS(dir), &static.Config{Browse: true})
+	s.Router().StaticFile("/robots.txt", filepath.Join(dir, "robots.txt"))
+
+	serve := func(method, target string, header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/public/robots.txt", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "User-agent: *", w.Body.String())
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.False(t, strings.HasPrefix(etag, "W/"))
+
+	w = serve(http.MethodGet, "/public/robots.txt", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = serve(http.MethodHead, "/public/robots.txt", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// directory listing is off by default
+	w = serve(http.MethodGet, "/public/docs/", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(http.MethodGet, "/public/missing.txt", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "404 page not found", w.Body.String())
+	// the root of the files redirects to the directory path
+	w = serve(http.MethodGet, "/public", nil)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/public/", w.Header().Get("Location"))
+	w = serve(http.MethodGet, "/public/../../etc/passwd", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(http.MethodGet, "/browse/docs", nil)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/browse/docs/", w.Header().Get("Location"))
+	w = serve(http.MethodGet, "/browse/docs/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<a href="a&amp;b.txt">a&amp;b.txt</a>`)
+
+	w = serve(http.MethodGet, "/app/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<h1>app</h1>", w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	indexETag := w.Header().Get("ETag")
+	assert.Len(t, indexETag, 34)
+
+	// precompressed siblings
+	w = serve(http.MethodGet, "/app/app.js", http.Header{"Accept-Encoding": {"gzip, br"}})
+	assert.Equal(t, "brotli", w.Body.String())
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "text/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	w = serve(http.MethodGet, "/app/app.js", http.Header{"Accept-Encoding": {"gzip, br;q=0"}})
+	assert.Equal(t, "gzipped", w.Body.String())
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	w = serve(http.MethodGet, "/app/app.js", nil)
+	assert.Equal(t, "console.log(1)", w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+	// the single page application handles the unmatched paths
+	w = serve(http.MethodGet, "/app/users/42", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<h1>app</h1>", w.Body.String())
+	assert.Equal(t, indexETag, w.Header().Get("ETag"))
+	w = serve(http.MethodPost, "/app/users/42", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = serve(http.MethodGet, "/robots.txt", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "User-agent: *", w.Body.String())
+
+	assert.Panics(t, func() {
+		s.Router().Static("/files/:id", dir)
+	})
+}
+
+func TestServerStaticRoot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":          {Data: []byte("<h1>app</h1>")},
+		"evil.com/index.html": {Data: []byte("evil")},
+	}
+	s := New()
+	s.Router().StaticFS("/", fsys, nil)
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(method, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code, method)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"), method)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "<h1>app</h1>", w.Body.String())
+
+	// the directory redirect stays on the host
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "//evil.com", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/evil.com/", w.Header().Get("Location"))
+}
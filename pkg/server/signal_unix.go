@@ -0,0 +1,16 @@
+//go:build !windows
+
+package server
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSupported is true when the listener can be handed over to a child process
+const upgradeSupported = true
+
+var (
+	shutdownSignals           = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	upgradeSignal   os.Signal = syscall.SIGUSR2
+)
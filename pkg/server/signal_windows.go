@@ -0,0 +1,14 @@
+//go:build windows
+
+package server
+
+import "os"
+
+// upgradeSupported is false since exec.Cmd.ExtraFiles is not supported on windows
+const upgradeSupported = false
+
+var (
+	shutdownSignals = []os.Signal{os.Interrupt}
+	// upgrades are not supported, see ErrUpgradeNotSupported
+	upgradeSignal os.Signal
+)
@@ -0,0 +1,284 @@
+package server
+
+// This file contains the logic for graceful shutdown and zero-downtime
+// upgrades. An upgrade starts a new copy of the running binary which inherits
+// the listening socket. Once the child reports it is ready the parent stops
+// accepting connections, drains the in-flight requests and exits.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// envListenFD holds the file descriptor of the listener inherited from the parent
+	envListenFD = "HTTPSERVER_LISTEN_FD"
+	// envReadyFD holds the file descriptor the child uses to report it is ready
+	envReadyFD = "HTTPSERVER_READY_FD"
+)
+
+// ErrUpgradeNotSupported is returned by Upgrade on platforms that cannot hand
+// the listener over to a child process.
+var ErrUpgradeNotSupported = errors.New("upgrade is not supported on this platform")
+
+// ErrUpgradeInProgress is returned by Upgrade when an other upgrade of the
+// server is in progress or already handed the listener over.
+var ErrUpgradeInProgress = errors.New("upgrade already in progress")
+
+// runState is the state of a running http server
+type runState struct {
+	srv      *http.Server
+	listener net.Listener
+	// done is closed when the graceful shutdown completed
+	done chan struct{}
+	once sync.Once
+	err  error
+	// upgrading is set while an upgrade is in progress and stays set once
+	// the listener is handed over
+	upgrading atomic.Bool
+}
+
+func (r *server) serve(ln net.Listener) error {
+	st := &runState{
+		srv:      &http.Server{Handler: r.Handler()},
+		listener: ln,
+		done:     make(chan struct{}),
+	}
+	r.m.Lock()
+	r.run = st
+	r.m.Unlock()
+
+	stop := r.handleSignals(st)
+	defer stop()
+
+	// the listener is bound, so connections queue up until Serve accepts them
+	if err := notifyReady(); err != nil {
+		ln.Close()
+		return err
+	}
+
+	err := st.srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		// wait until the in-flight requests are drained
+		<-st.done
+		return st.err
+	}
+	return err
+}
+
+func (r *server) running() *runState {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.run
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests
+// to complete until the context is done.
+func (r *server) Shutdown(ctx context.Context) error {
+	st := r.running()
+	if st == nil {
+		return nil
+	}
+	st.once.Do(func() {
+		st.err = st.srv.Shutdown(ctx)
+		close(st.done)
+	})
+	<-st.done
+	return st.err
+}
+
+func (r *server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.ShutdownTimeout)
+	defer cancel()
+	return r.Shutdown(ctx)
+}
+
+// Upgrade starts a new copy of the running binary and hands it the listener.
+// When the child reports it is ready a graceful shutdown is started in the
+// background and Upgrade returns, so it can be called from a handler; Run
+// returns once the in-flight requests are drained.
+// When the child fails to become ready the server keeps serving and an error
+// is returned. Concurrent upgrades return ErrUpgradeInProgress, on windows
+// ErrUpgradeNotSupported is returned.
+func (r *server) Upgrade() error {
+	if !upgradeSupported {
+		return ErrUpgradeNotSupported
+	}
+	st := r.running()
+	if st == nil {
+		return errors.New("cannot upgrade a server that is not running")
+	}
+	if !st.upgrading.CompareAndSwap(false, true) {
+		return ErrUpgradeInProgress
+	}
+	if err := r.startChild(st); err != nil {
+		st.upgrading.Store(false)
+		return err
+	}
+	// the child accepts the new connections, drain ours in the background,
+	// the error of the shutdown is returned by Run
+	go r.shutdown()
+	return nil
+}
+
+// startChild starts the new copy of the running binary with the listener
+// and waits until it reports it is ready.
+func (r *server) startChild(st *runState) error {
+	lnFile, err := listenerFile(st.listener)
+	if err != nil {
+		return err
+	}
+	defer lnFile.Close()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+
+	path, err := os.Executable()
+	if err != nil {
+		readyW.Close()
+		return err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// ExtraFiles[i] becomes file descriptor 3+i in the child
+	cmd.ExtraFiles = []*os.File{lnFile, readyW}
+	cmd.Env = append(childEnv(os.Environ()), envListenFD+"=3", envReadyFD+"=4")
+	if err := cmd.Start(); err != nil {
+		readyW.Close()
+		return err
+	}
+	// close our copy so a read on readyR fails when the child exits
+	readyW.Close()
+
+	ready := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1)
+		_, err := readyR.Read(b)
+		ready <- err
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return fmt.Errorf("upgrade failed, child exited before it was ready: %w", err)
+		}
+	case <-time.After(r.cfg.UpgradeTimeout):
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("upgrade failed, child not ready within %s", r.cfg.UpgradeTimeout)
+	}
+	return nil
+}
+
+// handleSignals shuts down the server on shutdownSignals and upgrades it
+// on upgradeSignal. The returned function stops the signal handling.
+func (r *server) handleSignals(st *runState) func() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, shutdownSignals...)
+	if upgradeSignal != nil {
+		signal.Notify(sigs, upgradeSignal)
+	}
+	go func() {
+		for {
+			select {
+			case sig, ok := <-sigs:
+				if !ok {
+					return
+				}
+				if sig == upgradeSignal {
+					// keep handling signals while the requests are drained
+					if err := r.Upgrade(); err != nil {
+						log.Printf("httpserver: %v", err)
+					}
+					continue
+				}
+				if err := r.shutdown(); err != nil {
+					log.Printf("httpserver: graceful shutdown: %v", err)
+				}
+				return
+			case <-st.done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+	}
+}
+
+// listen returns the listener inherited from the parent when started by an upgrade,
+// otherwise a new tcp listener on the address.
+func listen(address string) (net.Listener, error) {
+	if v := os.Getenv(envListenFD); v != "" {
+		os.Unsetenv(envListenFD)
+		fd, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", envListenFD, v)
+		}
+		f := os.NewFile(uintptr(fd), "listener")
+		defer f.Close()
+		// FileListener duplicates the file descriptor
+		return net.FileListener(f)
+	}
+	if address == "" {
+		address = ":http"
+	}
+	return net.Listen("tcp", address)
+}
+
+// notifyReady reports to the parent that started this process through
+// Upgrade that the server is ready to accept connections.
+func notifyReady() error {
+	v := os.Getenv(envReadyFD)
+	if v == "" {
+		return nil
+	}
+	os.Unsetenv(envReadyFD)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", envReadyFD, v)
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+	_, err = f.Write([]byte{1})
+	return err
+}
+
+func listenerFile(ln net.Listener) (*os.File, error) {
+	l, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, fmt.Errorf("listener %T cannot be handed over", ln)
+	}
+	return l.File()
+}
+
+// childEnv removes the handoff variables of a previous upgrade from env
+func childEnv(env []string) []string {
+	e := make([]string, 0, len(env)+2)
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envListenFD+"=") || strings.HasPrefix(kv, envReadyFD+"=") {
+			continue
+		}
+		e = append(e, kv)
+	}
+	return e
+}
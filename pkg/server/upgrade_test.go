@@ -0,0 +1,275 @@
+//go:build !windows
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/idproxy/httpserver/pkg/hctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// envHelperAddr runs TestUpgradeHelperProcess as a server on the address
+	envHelperAddr = "HTTPSERVER_TEST_HELPER_ADDR"
+	// envHelperFailReady makes the upgraded helper exit before it is ready
+	envHelperFailReady = "HTTPSERVER_TEST_HELPER_FAIL_READY"
+)
+
+// TestUpgradeHelperProcess is not a real test, it is the server process of
+// the upgrade tests. The upgrade re-executes it with the same arguments, so
+// the upgraded child runs it too, serving on the inherited listener.
+func TestUpgradeHelperProcess(t *testing.T) {
+	addr := os.Getenv(envHelperAddr)
+	if addr == "" {
+		return
+	}
+	if os.Getenv(envListenFD) != "" && os.Getenv(envHelperFailReady) != "" {
+		os.Exit(3)
+	}
+	s := NewWithConfig(Config{ShutdownTimeout: 5 * time.Second, UpgradeTimeout: 5 * time.Second})
+	s.Router().GET("/pid", func(c hctx.Context) {
+		c.String(http.StatusOK, "%d", os.Getpid())
+	})
+	s.Router().GET("/slow", func(c hctx.Context) {
+		time.Sleep(time.Second)
+		c.String(http.StatusOK, "%d", os.Getpid())
+	})
+	s.Router().GET("/upgrade", func(c hctx.Context) {
+		// the upgrade does not wait for the request calling it
+		if err := s.Upgrade(); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "upgraded")
+	})
+	if err := s.Run(addr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// startHelper starts the server process of TestUpgradeHelperProcess on a free address
+func startHelper(t *testing.T, env ...string) (*exec.Cmd, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestUpgradeHelperProcess$")
+	cmd.Env = append(os.Environ(), append(env, envHelperAddr+"="+addr)...)
+	// a pipe would keep cmd.Wait waiting for the upgraded child holding it
+	cmd.Stderr = os.Stderr
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+	})
+	return cmd, addr
+}
+
+// get returns the body of the response to the GET request
+func get(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+// waitPID waits until the server on the address answers and returns its pid
+func waitPID(t *testing.T, addr string) int {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		body, err := get("http://" + addr + "/pid")
+		if err == nil {
+			pid, err := strconv.Atoi(body)
+			require.NoError(t, err, body)
+			return pid
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server on %s not ready: %v", addr, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// waitExit waits until the process exits and returns its error
+func waitExit(t *testing.T, cmd *exec.Cmd) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("process did not exit")
+		return nil
+	}
+}
+
+func TestServerUpgrade(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts server processes")
+	}
+	cmd, addr := startHelper(t)
+	parentPID := waitPID(t, addr)
+	assert.Equal(t, cmd.Process.Pid, parentPID)
+
+	// a request in-flight during the upgrade is completed by the parent
+	slow := make(chan string, 1)
+	go func() {
+		body, err := get("http://" + addr + "/slow")
+		if err != nil {
+			body = err.Error()
+		}
+		slow <- body
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	require.NoError(t, cmd.Process.Signal(syscall.SIGUSR2))
+	// the parent exits once the child is ready and the requests are drained
+	assert.NoError(t, waitExit(t, cmd))
+	assert.Equal(t, strconv.Itoa(parentPID), <-slow)
+
+	// the child serves on the inherited listener
+	childPID := waitPID(t, addr)
+	assert.NotEqual(t, parentPID, childPID)
+	child, err := os.FindProcess(childPID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		child.Kill()
+	})
+
+	// the child shuts down gracefully on SIGTERM
+	require.NoError(t, child.Signal(syscall.SIGTERM))
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := get("http://" + addr + "/pid"); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("child did not shut down")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestServerUpgradeChildNotReady(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts server processes")
+	}
+	cmd, addr := startHelper(t, envHelperFailReady+"=1")
+	parentPID := waitPID(t, addr)
+
+	body, err := get("http://" + addr + "/upgrade")
+	require.NoError(t, err)
+	assert.Contains(t, body, "child exited before it was ready")
+
+	// the parent keeps serving
+	assert.Equal(t, parentPID, waitPID(t, addr))
+
+	require.NoError(t, cmd.Process.Signal(syscall.SIGTERM))
+	assert.NoError(t, waitExit(t, cmd))
+}
+
+func TestServerUpgradeFromHandler(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts server processes")
+	}
+	cmd, addr := startHelper(t)
+	parentPID := waitPID(t, addr)
+
+	start := time.Now()
+	body, err := get("http://" + addr + "/upgrade")
+	require.NoError(t, err)
+	assert.Equal(t, "upgraded", body)
+	assert.NoError(t, waitExit(t, cmd))
+	// the shutdown did not wait for the handler until the ShutdownTimeout
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	childPID := waitPID(t, addr)
+	assert.NotEqual(t, parentPID, childPID)
+	child, err := os.FindProcess(childPID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		child.Kill()
+	})
+	require.NoError(t, child.Signal(syscall.SIGTERM))
+}
+
+func TestServerUpgradeNotRunning(t *testing.T) {
+	assert.EqualError(t, New().Upgrade(), "cannot upgrade a server that is not running")
+}
+
+func TestServerUpgradeInProgress(t *testing.T) {
+	s := New().(*server)
+	st := &runState{}
+	st.upgrading.Store(true)
+	s.run = st
+	assert.Equal(t, ErrUpgradeInProgress, s.Upgrade())
+}
+
+func TestListenInherited(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	f, err := listenerFile(ln)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// listen closes the inherited descriptor
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	t.Setenv(envListenFD, strconv.Itoa(fd))
+	inherited, err := listen("")
+	require.NoError(t, err)
+	defer inherited.Close()
+	assert.Equal(t, ln.Addr().String(), inherited.Addr().String())
+	// the variable is not passed on to further children
+	assert.Empty(t, os.Getenv(envListenFD))
+
+	t.Setenv(envListenFD, "three")
+	_, err = listen("")
+	assert.EqualError(t, err, "invalid HTTPSERVER_LISTEN_FD: three")
+}
+
+func TestNotifyReady(t *testing.T) {
+	t.Setenv(envReadyFD, "")
+	assert.NoError(t, notifyReady())
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	// notifyReady closes the inherited descriptor
+	fd, err := syscall.Dup(int(w.Fd()))
+	require.NoError(t, err)
+	w.Close()
+	t.Setenv(envReadyFD, strconv.Itoa(fd))
+	require.NoError(t, notifyReady())
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, b)
+
+	t.Setenv(envReadyFD, "-")
+	assert.EqualError(t, notifyReady(), "invalid HTTPSERVER_READY_FD: -")
+}
+
+func TestChildEnv(t *testing.T) {
+	env := childEnv([]string{"A=1", envListenFD + "=3", "B=2", envReadyFD + "=4"})
+	assert.Equal(t, "A=1,B=2", strings.Join(env, ","))
+}
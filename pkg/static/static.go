@@ -0,0 +1,295 @@
+package static
+
+// This package serves the files of a file system, like a directory on disk
+// or an embed.FS, on the catch-all routes added by Router.Static and
+// Router.StaticFS.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/idproxy/httpserver/pkg/hctx"
+	"github.com/idproxy/httpserver/pkg/render"
+)
+
+// Param is the name of the catch-all parameter holding the path of the file.
+const Param = "filepath"
+
+// DefaultIndex is the file served for a directory.
+const DefaultIndex = "index.html"
+
+// Config configures how the files are served.
+type Config struct {
+	// Index is the file served for a directory, DefaultIndex when empty.
+	Index string
+	// Browse lists the content of directories without index file,
+	// directories are not found when false.
+	Browse bool
+	// SPA serves the index file of the root for the GET and HEAD requests not
+	// matching a file, so the client side router of a single page application
+	// handles them. Only paths without file extension and requests accepting
+	// text/html fall back to the index, a missing asset like /app.js is not found.
+	SPA bool
+}
+
+// encodings are the precompressed siblings in order of preference,
+// served when the client accepts their content encoding
+var encodings = []struct {
+	name      string
+	extension string
+}{
+	{name: "br", extension: ".br"},
+	{name: "gzip", extension: ".gz"},
+}
+
+// Handler returns a handler serving the file of the file system named by the
+// catch-all parameter Param, with the default config when nil.
+//
+// A sibling file with the .br or .gz extension is served instead of the file
+// when the client accepts its encoding. The responses have a strong ETag,
+// the hash of the content of the file, which is computed again when the
+// modification time or the size of the file changes.
+func Handler(fsys fs.FS, cfg *Config) hctx.HandlerFunc {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	s := &server{fsys: fsys, cfg: *cfg}
+	if s.cfg.Index == "" {
+		s.cfg.Index = DefaultIndex
+	}
+	return s.serve
+}
+
+type server struct {
+	fsys fs.FS
+	cfg  Config
+	// etags contains the fileETag of the files by name
+	etags sync.Map
+}
+
+// fileETag is the content hash of a file, valid as long as the file has the
+// modification time and the size
+type fileETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+func (s *server) serve(c hctx.Context) {
+	p, _ := c.GetParams().Get(Param)
+	name := path.Clean("/" + p)[1:]
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		// redirect to the canonical directory path, so relative links resolve
+		if urlPath := c.GetRequest().URL.Path; !strings.HasSuffix(urlPath, "/") {
+			c.Redirect(http.StatusMovedPermanently, dirLocation(urlPath))
+			return
+		}
+		index := path.Join(name, s.cfg.Index)
+		if indexInfo, err := fs.Stat(s.fsys, index); err == nil && !indexInfo.IsDir() {
+			s.serveFile(c, index)
+			return
+		}
+		if s.cfg.Browse {
+			s.serveDir(c, name)
+			return
+		}
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && s.cfg.SPA && s.fallback(c.GetRequest(), name) {
+			s.serveFile(c, s.cfg.Index)
+			return
+		}
+		s.abort(c, err)
+		return
+	}
+	s.serveFile(c, name)
+}
+
+// serveFile serves the regular file or its precompressed sibling
+func (s *server) serveFile(c hctx.Context, name string) {
+	header := c.Writer().Header()
+	served := name
+	encoding := ""
+	for _, e := range encodings {
+		info, err := fs.Stat(s.fsys, name+e.extension)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		// the response depends on the accepted encodings, also when not compressed
+		header.Set("Vary", "Accept-Encoding")
+		if encoding == "" && acceptsEncoding(c.GetRequest(), e.name) {
+			served, encoding = name+e.extension, e.name
+		}
+	}
+
+	f, err := s.fsys.Open(served)
+	if err != nil {
+		s.abort(c, err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		s.abort(c, err)
+		return
+	}
+	if info.IsDir() {
+		s.abort(c, fmt.Errorf("static: %s: %w", served, fs.ErrNotExist))
+		return
+	}
+	etag, err := s.etag(served, f, info)
+	if err != nil {
+		s.abort(c, err)
+		return
+	}
+	headers := map[string]string{"ETag": etag}
+	if encoding != "" {
+		headers["Content-Encoding"] = encoding
+	}
+	c.Render(http.StatusOK, render.Reader{
+		// the content type is the one of the uncompressed file
+		ContentType:   mime.TypeByExtension(path.Ext(name)),
+		ContentLength: info.Size(),
+		Reader:        f,
+		Headers:       headers,
+		Request:       c.GetRequest(),
+		ModTime:       info.ModTime(),
+	})
+}
+
+// fallback returns true when the single page application handles the request
+// of the missing file: the path has no file extension or the client accepts HTML
+func (s *server) fallback(r *http.Request, name string) bool {
+	return path.Ext(name) == "" || acceptsHTML(r)
+}
+
+// etag returns the strong entity tag of the file, the hash of its content
+func (s *server) etag(name string, f fs.File, info fs.FileInfo) (string, error) {
+	if v, ok := s.etags.Load(name); ok {
+		if e := v.(fileETag); e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return e.etag, nil
+		}
+	}
+	// the file is rewound to serve it after hashing
+	seeker, ok := f.(io.Seeker)
+	if !ok {
+		return "", fmt.Errorf("static: %s: file is not seekable", name)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(name, fileETag{modTime: info.ModTime(), size: info.Size(), etag: etag})
+	return etag, nil
+}
+
+// serveDir lists the entries of the directory
+func (s *server) serveDir(c hctx.Context, name string) {
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		s.abort(c, err)
+		return
+	}
+	var b strings.Builder
+	b.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, e := range entries {
+		entry := e.Name()
+		if e.IsDir() {
+			entry += "/"
+		}
+		link := url.URL{Path: entry}
+		fmt.Fprintf(&b, "<a href=\"%s\">%s</a>\n", html.EscapeString(link.String()), html.EscapeString(entry))
+	}
+	b.WriteString("</pre>\n")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(b.String()))
+}
+
+// abort aborts the request with the status code of the error, the body is
+// written by the error handler of the server
+func (s *server) abort(c hctx.Context, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrInvalid):
+		c.AbortWithError(http.StatusNotFound, err)
+	case errors.Is(err, fs.ErrPermission):
+		c.AbortWithError(http.StatusForbidden, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
+
+// dirLocation returns the escaped location of the directory with a trailing
+// slash. The path is cleaned and escaped, so a path like "//evil.com" or
+// "/\evil.com" can not redirect to another host.
+func dirLocation(urlPath string) string {
+	location := path.Clean("/" + urlPath)
+	if location != "/" {
+		location += "/"
+	}
+	return (&url.URL{Path: location}).EscapedPath()
+}
+
+// acceptsEncoding returns true when the Accept-Encoding header of the request
+// accepts the content encoding, explicitly or with the "*" wildcard
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	accepted, wildcard := false, false
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(part, ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			switch coding {
+			case encoding:
+				return acceptableQuality(params)
+			case "*":
+				accepted, wildcard = acceptableQuality(params), true
+			}
+		}
+	}
+	return wildcard && accepted
+}
+
+// acceptsHTML returns true when the Accept header of the request explicitly
+// accepts text/html
+func acceptsHTML(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, params, _ := strings.Cut(part, ";")
+			if strings.EqualFold(strings.TrimSpace(mediaType), "text/html") {
+				return acceptableQuality(params)
+			}
+		}
+	}
+	return false
+}
+
+// acceptableQuality returns false when the params have a zero quality value
+func acceptableQuality(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		k, v, _ := strings.Cut(param, "=")
+		if strings.TrimSpace(k) == "q" {
+			return strings.Trim(strings.TrimSpace(v), "0.") != ""
+		}
+	}
+	return true
+}
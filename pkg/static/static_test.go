@@ -0,0 +1,289 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/idproxy/httpserver/pkg/hctx"
+	"github.com/idproxy/httpserver/pkg/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve calls the handler with the file path as catch-all parameter
+func serve(handler hctx.HandlerFunc, target, filePath string, header http.Header) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	c := hctx.NewContext()
+	c.Init(&hctx.Config{
+		Writer:  w,
+		Request: req,
+		Params:  params.New(16),
+	})
+	c.GetParams().Add(params.Param{Key: Param, Value: filePath})
+	handler(c)
+	c.Writer().WriteHeaderNow()
+	return w
+}
+
+func TestHandlerIndex(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte("root")},
+		"docs/index.html": {Data: []byte("docs")},
+		"docs/a.txt":      {Data: []byte("a")},
+		"empty/a.txt":     {Data: []byte("a")},
+	}
+	handler := Handler(fsys, nil)
+
+	w := serve(handler, "/", "/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "root", w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+	// the root of the files without catch-all parameter
+	w = serve(handler, "/", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "root", w.Body.String())
+
+	w = serve(handler, "/static/docs/", "/docs/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "docs", w.Body.String())
+
+	w = serve(handler, "/static/docs", "/docs", nil)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/static/docs/", w.Header().Get("Location"))
+
+	w = serve(handler, "/static", "", nil)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/static/", w.Header().Get("Location"))
+
+	// without index and browsing the directory is not found
+	w = serve(handler, "/static/empty/", "/empty/", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(Handler(fsys, &Config{Index: "a.txt"}), "/static/docs/", "/docs/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a", w.Body.String())
+}
+
+func TestHandlerRedirectSameHost(t *testing.T) {
+	fsys := fstest.MapFS{
+		"evil.com/index.html": {Data: []byte("evil")},
+	}
+	handler := Handler(fsys, nil)
+
+	for _, target := range []string{"//evil.com", "///evil.com", "/./evil.com"} {
+		w := serve(handler, target, target, nil)
+		assert.Equal(t, http.StatusMovedPermanently, w.Code, target)
+		assert.Equal(t, "/evil.com/", w.Header().Get("Location"), target)
+	}
+}
+
+func TestDirLocation(t *testing.T) {
+	tests := map[string]string{
+		"/docs":       "/docs/",
+		"//evil.com":  "/evil.com/",
+		"/a//b":       "/a/b/",
+		"/a/../b":     "/b/",
+		"/..":         "/",
+		"":            "/",
+		"/\\evil.com": "/%5Cevil.com/",
+		"/a b":        "/a%20b/",
+	}
+	for urlPath, want := range tests {
+		assert.Equal(t, want, dirLocation(urlPath), urlPath)
+	}
+}
+
+func TestHandlerPrecompressed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":      {Data: []byte("plain")},
+		"app.js.gz":   {Data: []byte("gzipped")},
+		"app.js.br":   {Data: []byte("brotli")},
+		"style.css":   {Data: []byte("plain")},
+		"logo.svg":    {Data: []byte("plain")},
+		"logo.svg.gz": {Mode: os.ModeDir},
+	}
+	handler := Handler(fsys, nil)
+
+	tests := []struct {
+		file           string
+		acceptEncoding string
+		body           string
+		encoding       string
+		vary           string
+	}{
+		{"/app.js", "gzip, br", "brotli", "br", "Accept-Encoding"},
+		{"/app.js", "gzip", "gzipped", "gzip", "Accept-Encoding"},
+		{"/app.js", "br;q=0, *", "gzipped", "gzip", "Accept-Encoding"},
+		{"/app.js", "", "plain", "", "Accept-Encoding"},
+		{"/style.css", "gzip, br", "plain", "", ""},
+		// a directory is not a precompressed sibling
+		{"/logo.svg", "gzip", "plain", "", ""},
+	}
+	for _, tt := range tests {
+		var header http.Header
+		if tt.acceptEncoding != "" {
+			header = http.Header{"Accept-Encoding": {tt.acceptEncoding}}
+		}
+		w := serve(handler, tt.file, tt.file, header)
+		assert.Equal(t, http.StatusOK, w.Code, tt.file)
+		assert.Equal(t, tt.body, w.Body.String(), tt.file, tt.acceptEncoding)
+		assert.Equal(t, tt.encoding, w.Header().Get("Content-Encoding"), tt.file, tt.acceptEncoding)
+		assert.Equal(t, tt.vary, w.Header().Get("Vary"), tt.file, tt.acceptEncoding)
+		// the content type is the one of the uncompressed file
+		assert.NotContains(t, w.Header().Get("Content-Type"), "gzip", tt.file)
+	}
+}
+
+func TestHandlerETag(t *testing.T) {
+	// without modification time the etag is derived from the content
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte("a")},
+		"b.txt": {Data: []byte("b")},
+	}
+	handler := Handler(fsys, nil)
+
+	w := serve(handler, "/a.txt", "/a.txt", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a", w.Body.String())
+	etag := w.Header().Get("ETag")
+	assert.Len(t, etag, 34)
+	assert.NotEqual(t, etag, serve(handler, "/b.txt", "/b.txt", nil).Header().Get("ETag"))
+	// the hash is cached
+	assert.Equal(t, etag, serve(handler, "/a.txt", "/a.txt", nil).Header().Get("ETag"))
+
+	w = serve(handler, "/a.txt", "/a.txt", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	w = serve(handler, "/a.txt", "/a.txt", http.Header{"If-None-Match": {`"other"`}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// files with the same modification time and size have their own etag
+	dir := t.TempDir()
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, content := range map[string]string{"c.txt": "c", "d.txt": "d"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		require.NoError(t, os.Chtimes(filepath.Join(dir, name), modTime, modTime))
+	}
+	handler = Handler(os.DirFS(dir), nil)
+
+	w = serve(handler, "/c.txt", "/c.txt", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag = w.Header().Get("ETag")
+	assert.Len(t, etag, 34)
+	assert.NotEqual(t, etag, serve(handler, "/d.txt", "/d.txt", nil).Header().Get("ETag"))
+
+	w = serve(handler, "/c.txt", "/c.txt", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	w = serve(handler, "/c.txt", "/c.txt", http.Header{"If-Modified-Since": {modTime.Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// the content is hashed again when the file changes
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.txt"), []byte("e"), 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "c.txt"), modTime.Add(time.Second), modTime.Add(time.Second)))
+	w = serve(handler, "/c.txt", "/c.txt", nil)
+	assert.Equal(t, "e", w.Body.String())
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestHandlerSPA(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte("app")},
+		"app.js":     {Data: []byte("js")},
+	}
+
+	w := serve(Handler(fsys, &Config{SPA: true}), "/users/42", "/users/42", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "app", w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = serve(Handler(fsys, &Config{SPA: true}), "/app.js", "/app.js", nil)
+	assert.Equal(t, "js", w.Body.String())
+
+	// missing assets are not found
+	for _, p := range []string{"/main.js", "/img/x.png"} {
+		w = serve(Handler(fsys, &Config{SPA: true}), p, p, http.Header{"Accept": {"*/*"}})
+		assert.Equal(t, http.StatusNotFound, w.Code, p)
+	}
+
+	// a page with a dot in its path is requested by the browser as HTML
+	w = serve(Handler(fsys, &Config{SPA: true}), "/users/john.doe", "/users/john.doe",
+		http.Header{"Accept": {"text/html,application/xhtml+xml,*/*;q=0.8"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "app", w.Body.String())
+
+	w = serve(Handler(fsys, nil), "/users/42", "/users/42", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerTraversal(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0o600))
+	dir := filepath.Join(root, "public")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o600))
+	handler := Handler(os.DirFS(dir), nil)
+
+	for _, p := range []string{"/../secret.txt", "/../../secret.txt", "/a/../../secret.txt", "../secret.txt"} {
+		w := serve(handler, "/", p, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, p)
+		assert.NotContains(t, w.Body.String(), "secret", p)
+	}
+
+	// the cleaned path stays in the file system
+	w := serve(handler, "/", "/x/../a.txt", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a", w.Body.String())
+}
+
+func TestAcceptsHTML(t *testing.T) {
+	tests := map[string]bool{
+		"":                                  false,
+		"*/*":                               false,
+		"text/*":                            false,
+		"text/html":                         true,
+		"TEXT/HTML; charset=utf-8":          true,
+		"application/json, text/html;q=0.5": true,
+		"text/html;q=0":                     false,
+	}
+	for header, want := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			r.Header.Set("Accept", header)
+		}
+		assert.Equal(t, want, acceptsHTML(r), header)
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, GZIP;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip;q=0.000", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"*, gzip;q=0", false},
+		{"br", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Accept-Encoding", tt.header)
+		}
+		assert.Equal(t, tt.want, acceptsEncoding(r, "gzip"), tt.header)
+	}
+}
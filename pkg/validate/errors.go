@@ -0,0 +1,83 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldError describes a rule a field failed.
+type FieldError struct {
+	// Field is the path of the field, e.g. address.street or items[0].name
+	Field string `json:"field"`
+	// Rule is the name of the rule that failed
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule
+	Param string `json:"param,omitempty"`
+	// Message describes the failure
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// Errors is the list of fields that failed validation.
+type Errors []FieldError
+
+// Error implements the error interface.
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func newFieldError(path string, r rule, value reflect.Value) FieldError {
+	return FieldError{
+		Field:   path,
+		Rule:    r.name,
+		Param:   r.param,
+		Message: message(path, r, value),
+	}
+}
+
+func message(path string, r rule, value reflect.Value) string {
+	field := path
+	if field == "" {
+		field = "value"
+	}
+	unit := ""
+	switch value.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+	switch r.name {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s%s", field, r.param, unit)
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s%s", field, r.param, unit)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s%s", field, r.param, unit)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s%s", field, r.param, unit)
+	case "len":
+		return fmt.Sprintf("%s must be %s%s long", field, r.param, unit)
+	case "eq":
+		return fmt.Sprintf("%s must be equal to %s", field, r.param)
+	case "ne":
+		return fmt.Sprintf("%s must not be equal to %s", field, r.param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, r.param)
+	case "email", "url", "uuid", "ip", "hostname":
+		return fmt.Sprintf("%s must be a valid %s", field, r.name)
+	default:
+		return fmt.Sprintf("%s failed the %s rule", field, r)
+	}
+}
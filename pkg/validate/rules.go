@@ -0,0 +1,194 @@
+package validate
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	uuidRegex     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+)
+
+// builtinRules are the rules available in every Validator,
+// required and omitempty are handled by the validator itself.
+var builtinRules = map[string]Rule{
+	"min":      func(v reflect.Value, p string) bool { return compare(v, p, func(c int) bool { return c >= 0 }) },
+	"gte":      func(v reflect.Value, p string) bool { return compare(v, p, func(c int) bool { return c >= 0 }) },
+	"max":      func(v reflect.Value, p string) bool { return compare(v, p, func(c int) bool { return c <= 0 }) },
+	"lte":      func(v reflect.Value, p string) bool { return compare(v, p, func(c int) bool { return c <= 0 }) },
+	"gt":       func(v reflect.Value, p string) bool { return compare(v, p, func(c int) bool { return c > 0 }) },
+	"lt":       func(v reflect.Value, p string) bool { return compare(v, p, func(c int) bool { return c < 0 }) },
+	"len":      func(v reflect.Value, p string) bool { return compare(v, p, func(c int) bool { return c == 0 }) },
+	"eq":       func(v reflect.Value, p string) bool { return toString(v) == p },
+	"ne":       func(v reflect.Value, p string) bool { return toString(v) != p },
+	"oneof":    oneOf,
+	"email":    stringRule(isEmail),
+	"url":      stringRule(isURL),
+	"uuid":     stringRule(uuidRegex.MatchString),
+	"ip":       stringRule(func(s string) bool { return net.ParseIP(s) != nil }),
+	"hostname": stringRule(func(s string) bool { return len(s) <= 253 && hostnameRegex.MatchString(s) }),
+	"alpha":    stringRule(all(unicode.IsLetter)),
+	"alphanum": stringRule(all(func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })),
+	"numeric": stringRule(func(s string) bool {
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	}),
+	"contains": func(v reflect.Value, p string) bool {
+		return v.Kind() == reflect.String && strings.Contains(v.String(), p)
+	},
+	"startswith": func(v reflect.Value, p string) bool {
+		return v.Kind() == reflect.String && strings.HasPrefix(v.String(), p)
+	},
+	"endswith": func(v reflect.Value, p string) bool {
+		return v.Kind() == reflect.String && strings.HasSuffix(v.String(), p)
+	},
+}
+
+// compare compares the size of the value with the param, the size is the number
+// of characters of a string, the number of items of a slice, array or map and the
+// value of a number. The result of the comparison is passed to ok.
+func compare(v reflect.Value, param string, ok func(c int) bool) bool {
+	switch v.Kind() {
+	case reflect.String:
+		n, err := strconv.Atoi(param)
+		return err == nil && ok(cmpInt(int64(utf8.RuneCountInString(v.String())), int64(n)))
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n, err := strconv.Atoi(param)
+		return err == nil && ok(cmpInt(int64(v.Len()), int64(n)))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(param, 10, 64)
+		return err == nil && ok(cmpInt(v.Int(), n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case v.Uint() < n:
+			return ok(-1)
+		case v.Uint() > n:
+			return ok(1)
+		}
+		return ok(0)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case v.Float() < n:
+			return ok(-1)
+		case v.Float() > n:
+			return ok(1)
+		}
+		return ok(0)
+	}
+	return false
+}
+
+func cmpInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func oneOf(v reflect.Value, param string) bool {
+	s := toString(v)
+	for _, opt := range strings.Fields(param) {
+		if s == opt {
+			return true
+		}
+	}
+	return false
+}
+
+func toString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return ""
+}
+
+// stringRule turns a string check in a rule that fails for other kinds
+func stringRule(check func(string) bool) Rule {
+	return func(v reflect.Value, _ string) bool {
+		return v.Kind() == reflect.String && check(v.String())
+	}
+}
+
+func all(check func(rune) bool) func(string) bool {
+	return func(s string) bool {
+		for _, r := range s {
+			if !check(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	// reject addresses with a display name, like "Alice <alice@example.com>"
+	return err == nil && addr.Address == s
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// hasValue returns true when the value is not the zero value,
+// slices and maps must contain items
+func hasValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !v.IsNil()
+	}
+	return !isZero(v)
+}
+
+func isZero(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// deref returns the value a pointer points to, false is returned for nil pointers
+func deref(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.IsValid()
+}
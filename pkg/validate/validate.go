@@ -0,0 +1,246 @@
+package validate
+
+// This package validates structs based on rules declared in the `validate`
+// struct tag, e.g. `validate:"required,email,min=3"`.
+// Rules are separated by a comma, a rule takes an optional parameter after
+// the equal sign. Nested structs, pointers to structs and slices, arrays and
+// maps of structs are validated recursively.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	tagName      = "validate"
+	ruleSep      = ","
+	paramSep     = "="
+	omitEmpty    = "omitempty"
+	skipValidate = "-"
+)
+
+// Rule validates the value of a field, param is the parameter of the rule,
+// e.g. "3" for min=3. Pointers are dereferenced before the rule is called.
+type Rule func(field reflect.Value, param string) bool
+
+// Validator validates structs based on the rules in the `validate` struct tags.
+type Validator struct {
+	m     sync.RWMutex
+	rules map[string]Rule
+	// cache contains the parsed fields per struct type
+	cache sync.Map
+}
+
+// New returns a Validator with the built-in rules.
+func New() *Validator {
+	v := &Validator{
+		rules: make(map[string]Rule, len(builtinRules)),
+	}
+	for name, rule := range builtinRules {
+		v.rules[name] = rule
+	}
+	return v
+}
+
+// RegisterRule adds a rule, replacing an existing rule with the same name.
+func (v *Validator) RegisterRule(name string, rule Rule) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	v.rules[name] = rule
+	// parsed fields refer to the rules by name, so the cache remains valid
+}
+
+func (v *Validator) getRule(name string) (Rule, bool) {
+	v.m.RLock()
+	defer v.m.RUnlock()
+	rule, ok := v.rules[name]
+	return rule, ok
+}
+
+// ValidateStruct validates a struct, a pointer to a struct or a slice or array
+// of structs. Other types are not validated and nil is returned.
+// When validation fails Errors is returned.
+func (v *Validator) ValidateStruct(obj any) error {
+	if obj == nil {
+		return nil
+	}
+	var errs Errors
+	v.validateValue(reflect.ValueOf(obj), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Engine returns the Validator itself.
+func (v *Validator) Engine() any {
+	return v
+}
+
+// Var validates a single value against the rules, e.g. "required,email".
+func (v *Validator) Var(value any, rules string) error {
+	f, err := v.parseRules(rules)
+	if err != nil {
+		return err
+	}
+	var errs Errors
+	v.validateField(reflect.ValueOf(value), "", f, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateValue walks the value and validates the structs it finds
+func (v *Validator) validateValue(value reflect.Value, path string, errs *Errors) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		v.validateStruct(value, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			v.validateValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			v.validateValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key().Interface()), errs)
+		}
+	}
+}
+
+func (v *Validator) validateStruct(value reflect.Value, path string, errs *Errors) {
+	fields, err := v.structFields(value.Type())
+	if err != nil {
+		*errs = append(*errs, FieldError{Field: path, Rule: "invalid", Message: err.Error()})
+		return
+	}
+	for _, f := range fields {
+		fieldPath := f.name
+		if path != "" {
+			fieldPath = path + "." + f.name
+		}
+		fv := value.Field(f.index)
+		v.validateField(fv, fieldPath, f, errs)
+		if !f.skip {
+			v.validateValue(fv, fieldPath, errs)
+		}
+	}
+}
+
+// validateField applies the rules of the field on the value
+func (v *Validator) validateField(value reflect.Value, path string, f *field, errs *Errors) {
+	if f.omitEmpty && isZero(value) {
+		return
+	}
+	for _, r := range f.rules {
+		if r.name == "required" {
+			if !hasValue(value) {
+				*errs = append(*errs, newFieldError(path, r, value))
+				return
+			}
+			continue
+		}
+		// the other rules do not apply on nil pointers
+		elem, ok := deref(value)
+		if !ok {
+			return
+		}
+		rule, ok := v.getRule(r.name)
+		if !ok {
+			*errs = append(*errs, FieldError{Field: path, Rule: r.name, Message: fmt.Sprintf("unknown rule %q", r.name)})
+			return
+		}
+		if !rule(elem, r.param) {
+			*errs = append(*errs, newFieldError(path, r, elem))
+		}
+	}
+}
+
+type field struct {
+	index     int
+	name      string
+	rules     []rule
+	omitEmpty bool
+	// skip disables the validation of nested structs
+	skip bool
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+func (r rule) String() string {
+	if r.param == "" {
+		return r.name
+	}
+	return r.name + paramSep + r.param
+}
+
+// structFields returns the parsed fields of the struct type
+func (v *Validator) structFields(t reflect.Type) ([]*field, error) {
+	if fields, ok := v.cache.Load(t); ok {
+		return fields.([]*field), nil
+	}
+	fields := make([]*field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+		tag := sf.Tag.Get(tagName)
+		f, err := v.parseRules(tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+		f.index = i
+		f.name = fieldName(sf)
+		fields = append(fields, f)
+	}
+	v.cache.Store(t, fields)
+	return fields, nil
+}
+
+func (v *Validator) parseRules(tag string) (*field, error) {
+	f := &field{}
+	if tag == skipValidate {
+		f.skip = true
+		return f, nil
+	}
+	for _, s := range strings.Split(tag, ruleSep) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(s, paramSep)
+		if name == omitEmpty {
+			f.omitEmpty = true
+			continue
+		}
+		if _, ok := v.getRule(name); !ok && name != "required" {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+		f.rules = append(f.rules, rule{name: name, param: param})
+	}
+	return f, nil
+}
+
+// fieldName returns the name of the field as seen by the client, based on
+// the tags used for binding and otherwise the name of the struct field.
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "uri", "query", "header", "form", "xml", "yaml", "toml"} {
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
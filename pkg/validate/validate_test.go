@@ -0,0 +1,135 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	Street string `json:"street" validate:"required,min=3"`
+	Zip    string `json:"zip" validate:"omitempty,len=4,numeric"`
+}
+
+type user struct {
+	Name     string    `json:"name" validate:"required,min=3,max=10"`
+	Email    string    `json:"email" validate:"required,email"`
+	Age      int       `json:"age" validate:"gte=18,lt=130"`
+	Role     string    `json:"role" validate:"oneof=admin user"`
+	Tags     []string  `json:"tags" validate:"max=2"`
+	Nick     *string   `json:"nick" validate:"omitempty,alphanum"`
+	Address  address   `json:"address"`
+	Previous []address `json:"previous"`
+	Website  string    `query:"site" validate:"omitempty,url"`
+	internal string    `validate:"required"`
+}
+
+func validUser() user {
+	return user{
+		Name:    "alice",
+		Email:   "alice@example.com",
+		Age:     30,
+		Role:    "admin",
+		Address: address{Street: "Main"},
+	}
+}
+
+func fields(err error) []string {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	f := make([]string, 0, len(errs))
+	for _, e := range errs {
+		f = append(f, e.Field+":"+e.Rule)
+	}
+	return f
+}
+
+func TestValidateStruct(t *testing.T) {
+	v := New()
+
+	u := validUser()
+	assert.NoError(t, v.ValidateStruct(u))
+	assert.NoError(t, v.ValidateStruct(&u))
+	assert.NoError(t, v.ValidateStruct([]user{u}))
+	assert.NoError(t, v.ValidateStruct("not a struct"))
+	assert.NoError(t, v.ValidateStruct(nil))
+
+	nick := "bad nick!"
+	u = user{
+		Name:     "al",
+		Email:    "Alice <alice@example.com>",
+		Age:      12,
+		Role:     "guest",
+		Tags:     []string{"a", "b", "c"},
+		Nick:     &nick,
+		Address:  address{Zip: "12"},
+		Previous: []address{{Street: "Main"}, {Street: "x", Zip: "abcd"}},
+		Website:  "example.com",
+	}
+	err := v.ValidateStruct(&u)
+	assert.Equal(t, []string{
+		"name:min",
+		"email:email",
+		"age:gte",
+		"role:oneof",
+		"tags:max",
+		"nick:alphanum",
+		"address.street:required",
+		"address.zip:len",
+		"previous[1].street:min",
+		"previous[1].zip:numeric",
+		"site:url",
+	}, fields(err))
+	assert.Contains(t, err.Error(), "name must be at least 3 characters")
+}
+
+func TestValidateRequired(t *testing.T) {
+	var s struct {
+		Name  string         `validate:"required"`
+		Ptr   *int           `validate:"required,min=1"`
+		Items []int          `validate:"required"`
+		Map   map[string]int `validate:"required"`
+	}
+	assert.Equal(t, []string{"Name:required", "Ptr:required", "Items:required", "Map:required"}, fields(New().ValidateStruct(s)))
+
+	zero := 0
+	s.Name, s.Ptr, s.Items, s.Map = "x", &zero, []int{1}, map[string]int{"a": 1}
+	// a pointer to the zero value is present, the other rules apply on the value
+	assert.Equal(t, []string{"Ptr:min"}, fields(New().ValidateStruct(s)))
+}
+
+func TestValidateCustomRule(t *testing.T) {
+	v := New()
+	var s struct {
+		Name string `json:"name" validate:"lowercase"`
+	}
+	s.Name = "Alice"
+
+	err := v.ValidateStruct(s)
+	assert.ErrorContains(t, err, `unknown rule "lowercase"`)
+
+	v = New()
+	v.RegisterRule("lowercase", func(field reflect.Value, _ string) bool {
+		return field.Kind() == reflect.String && strings.ToLower(field.String()) == field.String()
+	})
+	assert.Equal(t, []string{"name:lowercase"}, fields(v.ValidateStruct(s)))
+	assert.Contains(t, v.ValidateStruct(s).Error(), "name failed the lowercase rule")
+
+	s.Name = "alice"
+	assert.NoError(t, v.ValidateStruct(s))
+}
+
+func TestValidateVar(t *testing.T) {
+	v := New()
+	assert.NoError(t, v.Var("alice@example.com", "required,email"))
+	assert.Error(t, v.Var("", "required,email"))
+	assert.NoError(t, v.Var(5, "min=1,max=10"))
+	assert.Error(t, v.Var(11, "min=1,max=10"))
+	assert.NoError(t, v.Var("3f1b4a5e-8c6e-4f0e-9b7a-2a1c3d4e5f60", "uuid"))
+	assert.Error(t, v.Var(1.5, "gt=2"))
+}
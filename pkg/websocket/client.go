@@ -0,0 +1,133 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dialer connects to websocket servers.
+type Dialer struct {
+	// TLSConfig is used for wss urls.
+	TLSConfig *tls.Config
+	// Subprotocols are requested in the handshake.
+	Subprotocols []string
+	// ReadLimit is the maximum size of a message read from the server,
+	// DefaultReadLimit when 0 and no limit when negative, frames are still
+	// capped at 1 GiB.
+	ReadLimit int64
+}
+
+// Dial connects to the ws or wss url with the default Dialer.
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	return (&Dialer{}).Dial(ctx, rawURL, header)
+}
+
+// Dial connects to the ws or wss url, sending the header with the handshake.
+// When the server rejects the handshake ErrBadHandshake and the response are returned.
+func (d *Dialer) Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	var scheme, port string
+	switch u.Scheme {
+	case "ws":
+		scheme, port = "http", "80"
+	case "wss":
+		scheme, port = "https", "443"
+	default:
+		return nil, nil, fmt.Errorf("websocket: unsupported url scheme %q", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var nd net.Dialer
+	netConn, err := nd.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if scheme == "https" {
+		cfg := d.TLSConfig.Clone()
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(netConn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			netConn.Close()
+			return nil, nil, err
+		}
+		netConn = tlsConn
+	}
+	conn, resp, err := d.handshake(ctx, netConn, u, scheme, header)
+	if err != nil {
+		netConn.Close()
+		return nil, resp, err
+	}
+	return conn, resp, nil
+}
+
+func (d *Dialer) handshake(ctx context.Context, netConn net.Conn, u *url.URL, scheme string, header http.Header) (*Conn, *http.Response, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+		defer netConn.SetDeadline(time.Time{})
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	reqURL := *u
+	reqURL.Scheme = scheme
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &reqURL,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       u.Host,
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(d.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.Subprotocols, ", "))
+	}
+	if err := req.Write(netConn); err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContainsToken(resp.Header, "Upgrade", "websocket") ||
+		!headerContainsToken(resp.Header, "Connection", "upgrade") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, resp, ErrBadHandshake
+	}
+	conn := newConn(netConn, br, false, d.ReadLimit)
+	conn.subprotocol = resp.Header.Get("Sec-WebSocket-Protocol")
+	return conn, resp, nil
+}
@@ -0,0 +1,457 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultReadLimit is the maximum size of a message read when no limit is configured.
+	DefaultReadLimit = 1 << 20
+	// DefaultCloseTimeout bounds how long Close waits for the peer to
+	// acknowledge the close message.
+	DefaultCloseTimeout = 5 * time.Second
+
+	// maxFramePayloadLen caps the payload of a frame also without read limit
+	maxFramePayloadLen = 1 << 30
+	// preallocPayloadLen is the payload size allocated up front, a larger
+	// payload grows with the bytes actually received
+	preallocPayloadLen = 64 << 10
+
+	finalBit = 0x80
+	rsvBits  = 0x70
+	maskBit  = 0x80
+)
+
+// Conn is a websocket connection.
+// ReadMessage must not be called concurrently, the write methods are safe
+// for concurrent use.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	isServer    bool
+	subprotocol string
+
+	// rm serializes the readers of the connection
+	rm          sync.Mutex
+	readLimit   int64
+	pingHandler func(appData string) error
+	pongHandler func(appData string) error
+	// closeRecv is closed when the close message of the peer was read
+	closeRecv     chan struct{}
+	closeRecvOnce sync.Once
+	readErr       error
+
+	// wm serializes the writes of the connection
+	wm        sync.Mutex
+	closeSent bool
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isServer bool, readLimit int64) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	if readLimit == 0 {
+		readLimit = DefaultReadLimit
+	}
+	c := &Conn{
+		conn:      conn,
+		br:        br,
+		isServer:  isServer,
+		readLimit: readLimit,
+		closeRecv: make(chan struct{}),
+	}
+	c.pingHandler = func(appData string) error {
+		err := c.WriteControl(PongMessage, []byte(appData), time.Now().Add(time.Second))
+		if errors.Is(err, ErrCloseSent) {
+			return nil
+		}
+		return err
+	}
+	return c
+}
+
+// Subprotocol returns the subprotocol negotiated during the handshake.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// LocalAddr returns the local network address.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadLimit sets the maximum size of a message read from the peer,
+// a negative value disables the limit, frames are still capped at 1 GiB.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadDeadline sets the deadline of reading from the connection.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline of writing to the connection.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetPingHandler sets the handler of the ping messages received while reading,
+// by default a pong with the same application data is sent.
+func (c *Conn) SetPingHandler(h func(appData string) error) {
+	c.pingHandler = h
+}
+
+// SetPongHandler sets the handler of the pong messages received while reading.
+func (c *Conn) SetPongHandler(h func(appData string) error) {
+	c.pongHandler = h
+}
+
+// ReadMessage reads the next text or binary message, handling the control
+// messages received meanwhile. When the peer closes the connection the close
+// is acknowledged and a *CloseError is returned.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	c.rm.Lock()
+	defer c.rm.Unlock()
+	return c.readMessage()
+}
+
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	mt, p, err := c.nextMessage()
+	if err != nil {
+		c.readErr = err
+	}
+	return mt, p, err
+}
+
+func (c *Conn) nextMessage() (MessageType, []byte, error) {
+	var mt MessageType
+	message := []byte{}
+	for {
+		fin, opcode, payload, err := c.readFrame(int64(len(message)))
+		if err != nil {
+			return 0, nil, err
+		}
+		switch {
+		case opcode.isControl():
+			if err := c.handleControl(opcode, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opcode == continuationFrame:
+			if mt == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "continuation frame without a message")
+			}
+		case opcode == TextMessage || opcode == BinaryMessage:
+			if mt != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "new message before the previous one finished")
+			}
+			mt = opcode
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+		message = append(message, payload...)
+		if fin {
+			if mt == TextMessage && !utf8.Valid(message) {
+				return 0, nil, c.fail(CloseInvalidFramePayload, "invalid utf8 in text message")
+			}
+			return mt, message, nil
+		}
+	}
+}
+
+// readFrame reads a frame, read is the size of the message read so far
+func (c *Conn) readFrame(read int64) (fin bool, opcode MessageType, payload []byte, err error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	fin = h[0]&finalBit != 0
+	opcode = MessageType(h[0] & 0x0f)
+	if h[0]&rsvBits != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "unexpected reserved bits")
+	}
+	masked := h[1]&maskBit != 0
+	if masked != c.isServer {
+		if c.isServer {
+			return false, 0, nil, c.fail(CloseProtocolError, "client frame is not masked")
+		}
+		return false, 0, nil, c.fail(CloseProtocolError, "server frame is masked")
+	}
+
+	length := int64(h[1] & 0x7f)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = int64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		// the most significant bit must be 0 (RFC 6455 section 5.2)
+		if b[0]&0x80 != 0 {
+			return false, 0, nil, c.fail(CloseProtocolError, "invalid payload length")
+		}
+		length = int64(binary.BigEndian.Uint64(b[:]))
+	}
+	if opcode.isControl() {
+		if !fin || length > maxControlFramePayloadLen {
+			return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+		}
+	} else if length > maxFramePayloadLen || (c.readLimit > 0 && read+length > c.readLimit) {
+		c.fail(CloseMessageTooBig, "message too big")
+		return false, 0, nil, ErrReadLimit
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+	}
+	payload, err = c.readPayload(length)
+	if err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	if masked {
+		maskBytes(key, payload)
+	}
+	return fin, opcode, payload, nil
+}
+
+// readPayload reads the payload of a frame. A large payload is not allocated
+// up front, so a peer announcing a large frame without sending it does not
+// make the connection allocate its size.
+func (c *Conn) readPayload(length int64) ([]byte, error) {
+	if length <= preallocPayloadLen {
+		payload := make([]byte, length)
+		_, err := io.ReadFull(c.br, payload)
+		return payload, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(preallocPayloadLen)
+	if _, err := io.CopyN(&buf, c.br, length); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *Conn) handleControl(opcode MessageType, payload []byte) error {
+	switch opcode {
+	case PingMessage:
+		if c.pingHandler != nil {
+			return c.pingHandler(string(payload))
+		}
+	case PongMessage:
+		if c.pongHandler != nil {
+			return c.pongHandler(string(payload))
+		}
+	case CloseMessage:
+		ce := &CloseError{Code: CloseNoStatusReceived}
+		switch {
+		case len(payload) == 1:
+			return c.fail(CloseProtocolError, "invalid close payload")
+		case len(payload) >= 2:
+			ce.Code = int(binary.BigEndian.Uint16(payload))
+			ce.Text = string(payload[2:])
+			if !validCloseCode(ce.Code) {
+				return c.fail(CloseProtocolError, "invalid close code")
+			}
+			if !utf8.ValidString(ce.Text) {
+				return c.fail(CloseInvalidFramePayload, "invalid utf8 in close reason")
+			}
+		}
+		c.closeRecvOnce.Do(func() { close(c.closeRecv) })
+		// acknowledge the close with the same code
+		code := ce.Code
+		if code == CloseNoStatusReceived {
+			code = CloseNormalClosure
+		}
+		c.writeClose(code, "", time.Now().Add(time.Second))
+		return ce
+	}
+	return nil
+}
+
+// readError translates the error of reading from the connection
+func (c *Conn) readError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &CloseError{Code: CloseAbnormalClosure, Text: io.ErrUnexpectedEOF.Error()}
+	}
+	return err
+}
+
+// fail sends a close message with the code and returns the protocol error
+func (c *Conn) fail(code int, text string) error {
+	c.writeClose(code, text, time.Now().Add(time.Second))
+	return &CloseError{Code: code, Text: text}
+}
+
+// WriteMessage writes a text or binary message.
+func (c *Conn) WriteMessage(mt MessageType, data []byte) error {
+	if mt != TextMessage && mt != BinaryMessage {
+		return errors.New("websocket: WriteMessage requires a text or binary message type")
+	}
+	c.wm.Lock()
+	defer c.wm.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	return c.writeFrame(mt, data)
+}
+
+// WriteControl writes a control message with the deadline, use Close to close the connection.
+func (c *Conn) WriteControl(mt MessageType, data []byte, deadline time.Time) error {
+	if !mt.isControl() {
+		return errors.New("websocket: WriteControl requires a control message type")
+	}
+	if len(data) > maxControlFramePayloadLen {
+		return errors.New("websocket: control message payload too large")
+	}
+	c.wm.Lock()
+	defer c.wm.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if mt == CloseMessage {
+		c.closeSent = true
+	}
+	c.conn.SetWriteDeadline(deadline)
+	defer c.conn.SetWriteDeadline(time.Time{})
+	return c.writeFrame(mt, data)
+}
+
+// Ping sends a ping message, the pong is received by the pong handler while reading.
+func (c *Conn) Ping(data []byte) error {
+	return c.WriteControl(PingMessage, data, time.Now().Add(DefaultCloseTimeout))
+}
+
+func (c *Conn) writeClose(code int, text string, deadline time.Time) error {
+	if len(text) > maxControlFramePayloadLen-2 {
+		text = text[:maxControlFramePayloadLen-2]
+	}
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	return c.WriteControl(CloseMessage, payload, deadline)
+}
+
+// writeFrame writes the data as a single frame with one write, c.wm must be held
+func (c *Conn) writeFrame(mt MessageType, data []byte) error {
+	frame := make([]byte, 0, 14+len(data))
+	frame = append(frame, finalBit|byte(mt))
+	var mask byte
+	if !c.isServer {
+		mask = maskBit
+	}
+	switch n := len(data); {
+	case n <= 125:
+		frame = append(frame, mask|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, mask|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, mask|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if c.isServer {
+		frame = append(frame, data...)
+	} else {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		frame = append(frame, key[:]...)
+		start := len(frame)
+		frame = append(frame, data...)
+		maskBytes(key, frame[start:])
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Close closes the connection with a normal closure, see CloseWithCode.
+func (c *Conn) Close() error {
+	return c.CloseWithCode(CloseNormalClosure, "")
+}
+
+// CloseWithCode performs the closing handshake: it sends a close message with
+// the code and reason and waits up to DefaultCloseTimeout for the peer to
+// acknowledge it before the underlying connection is closed.
+// Messages received meanwhile are discarded when no ReadMessage is in progress.
+func (c *Conn) CloseWithCode(code int, reason string) error {
+	c.closeOnce.Do(func() {
+		deadline := time.Now().Add(DefaultCloseTimeout)
+		err := c.writeClose(code, reason, deadline)
+		if err == nil || errors.Is(err, ErrCloseSent) {
+			c.awaitClose(deadline)
+		}
+		c.closeErr = c.conn.Close()
+	})
+	return c.closeErr
+}
+
+// awaitClose waits until the close message of the peer is read
+func (c *Conn) awaitClose(deadline time.Time) {
+	select {
+	case <-c.closeRecv:
+		return
+	default:
+	}
+	if c.rm.TryLock() {
+		// no reader in progress, read until the close message of the peer
+		defer c.rm.Unlock()
+		c.conn.SetReadDeadline(deadline)
+		for {
+			if _, _, err := c.readMessage(); err != nil {
+				return
+			}
+		}
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-c.closeRecv:
+	case <-timer.C:
+	}
+}
+
+// validCloseCode returns true when the code can be sent in a close frame,
+// 1004 and the codes reserved for local use by RFC 6455 section 7.4.1 can not
+func validCloseCode(code int) bool {
+	switch code {
+	case 1004, CloseNoStatusReceived, CloseAbnormalClosure, CloseTLSHandshake:
+		return false
+	}
+	return (code >= 1000 && code <= 1014) || (code >= 3000 && code <= 4999)
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	}
+}
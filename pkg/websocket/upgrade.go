@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/idproxy/httpserver/pkg/hctx"
+)
+
+// Upgrader upgrades http requests to websocket connections.
+type Upgrader struct {
+	// ReadLimit is the maximum size of a message read from the peer,
+	// DefaultReadLimit when 0 and no limit when negative, frames are still
+	// capped at 1 GiB.
+	ReadLimit int64
+	// Subprotocols are the supported subprotocols in order of preference.
+	Subprotocols []string
+	// CheckOrigin returns true when the Origin of the request is allowed.
+	// When nil, requests with an Origin are only allowed when its host
+	// equals the Host of the request.
+	CheckOrigin func(r *http.Request) bool
+	// HandshakeTimeout bounds writing the handshake response, no timeout when 0.
+	HandshakeTimeout time.Duration
+}
+
+// HandlerFunc handles a websocket connection, the connection is closed when it returns.
+type HandlerFunc func(c hctx.Context, conn *Conn)
+
+// Handler returns a handler upgrading the request with the upgrader, the
+// default upgrader when nil, and calling fn with the connection.
+// A failed handshake aborts the request with its status.
+func Handler(u *Upgrader, fn HandlerFunc) hctx.HandlerFunc {
+	if u == nil {
+		u = &Upgrader{}
+	}
+	return func(c hctx.Context) {
+		conn, err := u.Upgrade(c.Writer(), c.GetRequest())
+		if err != nil {
+			c.Error(err).SetType(hctx.ErrorTypePrivate)
+			c.Abort()
+			return
+		}
+		defer conn.Close()
+		fn(c, conn)
+	}
+}
+
+// Upgrade performs the websocket handshake and returns the connection,
+// hijacked from the http server. When the request is not a valid websocket
+// handshake an error response is written and a *HandshakeError is returned.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, u.handshakeError(w, http.StatusMethodNotAllowed, "request method is not GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") {
+		return nil, u.handshakeError(w, http.StatusBadRequest, "'upgrade' token not found in 'Connection' header")
+	}
+	if !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, u.handshakeError(w, http.StatusBadRequest, "'websocket' token not found in 'Upgrade' header")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, u.handshakeError(w, http.StatusUpgradeRequired, "unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, u.handshakeError(w, http.StatusBadRequest, "invalid 'Sec-WebSocket-Key' header")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, u.handshakeError(w, http.StatusForbidden, "origin not allowed")
+	}
+	subprotocol := u.selectSubprotocol(r)
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, u.handshakeError(w, http.StatusInternalServerError, "response does not implement http.Hijacker")
+	}
+	netConn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// clear the deadlines set by the http server
+	netConn.SetDeadline(time.Time{})
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	b.WriteString(acceptKey(key))
+	b.WriteString("\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: ")
+		b.WriteString(subprotocol)
+		b.WriteString("\r\n")
+	}
+	b.WriteString("\r\n")
+	if u.HandshakeTimeout > 0 {
+		netConn.SetWriteDeadline(time.Now().Add(u.HandshakeTimeout))
+	}
+	if _, err := netConn.Write([]byte(b.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetWriteDeadline(time.Time{})
+
+	c := newConn(netConn, brw.Reader, true, u.ReadLimit)
+	c.subprotocol = subprotocol
+	return c, nil
+}
+
+func (u *Upgrader) handshakeError(w http.ResponseWriter, status int, msg string) error {
+	http.Error(w, http.StatusText(status), status)
+	return &HandshakeError{Status: status, Message: msg}
+}
+
+// selectSubprotocol returns the first subprotocol supported by the server
+// the client requested
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	for _, supported := range u.Subprotocols {
+		for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+			for _, requested := range strings.Split(v, ",") {
+				if strings.TrimSpace(requested) == supported {
+					return supported
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// sameOrigin returns true when the request has no Origin or the host of
+// the Origin equals the Host of the request
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,126 @@
+// Package websocket implements the WebSocket protocol, RFC 6455, on top of
+// the hijacked connection of an http request, and a client used to test it.
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MessageType is the opcode of a websocket frame.
+type MessageType int
+
+const (
+	continuationFrame MessageType = 0
+	// TextMessage denotes a text message, the payload is UTF-8 encoded text.
+	TextMessage MessageType = 1
+	// BinaryMessage denotes a binary message.
+	BinaryMessage MessageType = 2
+	// CloseMessage denotes a close control message.
+	CloseMessage MessageType = 8
+	// PingMessage denotes a ping control message.
+	PingMessage MessageType = 9
+	// PongMessage denotes a pong control message.
+	PongMessage MessageType = 10
+)
+
+func (t MessageType) isControl() bool {
+	return t >= CloseMessage
+}
+
+// Close codes defined in RFC 6455, section 7.4.1.
+const (
+	CloseNormalClosure        = 1000
+	CloseGoingAway            = 1001
+	CloseProtocolError        = 1002
+	CloseUnsupportedData      = 1003
+	CloseNoStatusReceived     = 1005
+	CloseAbnormalClosure      = 1006
+	CloseInvalidFramePayload  = 1007
+	ClosePolicyViolation      = 1008
+	CloseMessageTooBig        = 1009
+	CloseMandatoryExtension   = 1010
+	CloseInternalServerErr    = 1011
+	CloseServiceRestart       = 1012
+	CloseTryAgainLater        = 1013
+	CloseTLSHandshake         = 1015
+	maxControlFramePayloadLen = 125
+)
+
+var (
+	// ErrReadLimit is returned when a message exceeds the read limit of the connection.
+	ErrReadLimit = errors.New("websocket: message exceeds the read limit")
+	// ErrCloseSent is returned when writing to a connection after the close message was sent.
+	ErrCloseSent = errors.New("websocket: close sent")
+	// ErrBadHandshake is returned by Dial when the server does not accept the upgrade.
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+)
+
+// CloseError is returned by ReadMessage when the peer closed the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: close %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: close %d: %s", e.Code, e.Text)
+}
+
+// IsCloseError returns true when err is a *CloseError with one of the codes.
+func IsCloseError(err error, codes ...int) bool {
+	var ce *CloseError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	for _, code := range codes {
+		if ce.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// HandshakeError is returned by Upgrade when the request is not a valid
+// websocket handshake, the response was written with Status.
+type HandshakeError struct {
+	Status  int
+	Message string
+}
+
+func (e *HandshakeError) Error() string {
+	return "websocket: " + e.Message
+}
+
+// StatusCode returns the http status of the handshake response.
+func (e *HandshakeError) StatusCode() int {
+	return e.Status
+}
+
+// acceptGUID is the GUID appended to the key of the handshake, RFC 6455 section 1.3
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// acceptKey returns the Sec-WebSocket-Accept of the Sec-WebSocket-Key
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContainsToken returns true when the comma separated header contains the token
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, v := range header.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
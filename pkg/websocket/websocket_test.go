@@ -0,0 +1,253 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newServer(t *testing.T, u *Upgrader, fn func(conn *Conn)) (*httptest.Server, string) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := u.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fn(conn)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func echo(conn *Conn) {
+	for {
+		mt, p, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := conn.WriteMessage(mt, p); err != nil {
+			return
+		}
+	}
+}
+
+func TestEcho(t *testing.T) {
+	_, url := newServer(t, &Upgrader{}, echo)
+	conn, resp, err := Dial(context.Background(), url, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	defer conn.Close()
+
+	messages := []struct {
+		mt   MessageType
+		data []byte
+	}{
+		{TextMessage, []byte("hello")},
+		{BinaryMessage, bytes.Repeat([]byte{1}, 300)},
+		{BinaryMessage, bytes.Repeat([]byte{2}, 70000)},
+		{TextMessage, []byte{}},
+	}
+	for _, m := range messages {
+		assert.NoError(t, conn.WriteMessage(m.mt, m.data))
+		mt, p, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, m.mt, mt)
+		assert.Equal(t, m.data, p)
+	}
+}
+
+func TestPingPong(t *testing.T) {
+	_, url := newServer(t, &Upgrader{}, echo)
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(appData string) error {
+		pong <- appData
+		return nil
+	})
+	assert.NoError(t, conn.Ping([]byte("ping")))
+	// the pong is handled while reading the echo
+	assert.NoError(t, conn.WriteMessage(TextMessage, []byte("hello")))
+	_, p, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(p))
+	assert.Equal(t, "ping", <-pong)
+}
+
+func TestReadLimit(t *testing.T) {
+	errs := make(chan error, 1)
+	_, url := newServer(t, &Upgrader{ReadLimit: 10}, func(conn *Conn) {
+		_, _, err := conn.ReadMessage()
+		errs <- err
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	assert.NoError(t, conn.WriteMessage(TextMessage, []byte("a message over the limit")))
+	assert.ErrorIs(t, <-errs, ErrReadLimit)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseMessageTooBig), err)
+}
+
+func TestReadFrameLength(t *testing.T) {
+	tests := map[string]struct {
+		length []byte
+		limit  int64
+		err    error
+		code   int
+	}{
+		"most significant bit set": {
+			length: []byte{0x80, 0, 0, 0, 0, 0, 0, 1},
+			limit:  -1,
+			code:   CloseProtocolError,
+		},
+		"over the hard cap without limit": {
+			length: []byte{0, 0, 0x01, 0, 0, 0, 0, 0},
+			limit:  -1,
+			err:    ErrReadLimit,
+		},
+	}
+	for name, tt := range tests {
+		server, client := net.Pipe()
+		conn := newConn(server, bufio.NewReader(server), true, tt.limit)
+		go func() {
+			client.Write(append([]byte{0x82, maskBit | 127}, tt.length...))
+			io.Copy(io.Discard, client)
+		}()
+		_, _, err := conn.ReadMessage()
+		if tt.err != nil {
+			assert.ErrorIs(t, err, tt.err, name)
+		} else {
+			assert.True(t, IsCloseError(err, tt.code), name, err)
+		}
+		client.Close()
+		server.Close()
+	}
+
+	// a large announced frame is read as it arrives, not allocated up front
+	server, client := net.Pipe()
+	defer server.Close()
+	conn := newConn(server, bufio.NewReader(server), true, -1)
+	payload := bytes.Repeat([]byte("x"), preallocPayloadLen+1)
+	go func() {
+		frame := []byte{0x82, maskBit | 127, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint64(frame[2:10], uint64(len(payload)))
+		client.Write(append(frame, payload...))
+	}()
+	_, p, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, payload, p)
+}
+
+func TestReadCloseCode(t *testing.T) {
+	tests := map[int]int{
+		CloseGoingAway:        CloseGoingAway,
+		CloseTryAgainLater:    CloseTryAgainLater,
+		3000:                  3000,
+		1004:                  CloseProtocolError,
+		CloseNoStatusReceived: CloseProtocolError,
+		CloseAbnormalClosure:  CloseProtocolError,
+		CloseTLSHandshake:     CloseProtocolError,
+		1016:                  CloseProtocolError,
+		2999:                  CloseProtocolError,
+		5000:                  CloseProtocolError,
+	}
+	for code, want := range tests {
+		server, client := net.Pipe()
+		conn := newConn(server, bufio.NewReader(server), true, -1)
+		go func() {
+			frame := []byte{0x88, maskBit | 2, 0, 0, 0, 0, 0, 0}
+			binary.BigEndian.PutUint16(frame[6:], uint16(code))
+			client.Write(frame)
+			io.Copy(io.Discard, client)
+		}()
+		_, _, err := conn.ReadMessage()
+		assert.True(t, IsCloseError(err, want), code, err)
+		client.Close()
+		server.Close()
+	}
+}
+
+func TestClose(t *testing.T) {
+	errs := make(chan error, 1)
+	_, url := newServer(t, &Upgrader{}, func(conn *Conn) {
+		_, _, err := conn.ReadMessage()
+		errs <- err
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.NoError(t, conn.CloseWithCode(CloseGoingAway, "bye"))
+	assert.Less(t, time.Since(start), DefaultCloseTimeout)
+	err = <-errs
+	assert.True(t, IsCloseError(err, CloseGoingAway), err)
+	assert.Equal(t, "websocket: close 1001: bye", err.Error())
+	assert.ErrorIs(t, conn.WriteMessage(TextMessage, []byte("late")), ErrCloseSent)
+}
+
+func TestServerClose(t *testing.T) {
+	_, url := newServer(t, &Upgrader{}, func(conn *Conn) {
+		conn.WriteMessage(TextMessage, []byte("bye"))
+	})
+	conn, _, err := Dial(context.Background(), url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	_, p, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "bye", string(p))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseNormalClosure), err)
+}
+
+func TestHandshake(t *testing.T) {
+	ts, url := newServer(t, &Upgrader{Subprotocols: []string{"v2", "v1"}}, echo)
+
+	d := &Dialer{Subprotocols: []string{"v1", "v2"}}
+	conn, _, err := d.Dial(context.Background(), url, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", conn.Subprotocol())
+	conn.Close()
+
+	_, resp, err := Dial(context.Background(), url, http.Header{"Origin": {"http://evil.example.com"}})
+	assert.ErrorIs(t, err, ErrBadHandshake)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	_, resp, err = Dial(context.Background(), url, http.Header{"Origin": {ts.URL}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	resp, err = http.Get(ts.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "8")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+	assert.Equal(t, "13", resp.Header.Get("Sec-WebSocket-Version"))
+}
+
+func TestAcceptKey(t *testing.T) {
+	// example of RFC 6455 section 1.3
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}